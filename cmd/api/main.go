@@ -1,89 +1,258 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"schedule-api/config"
 	"schedule-api/handlers"
+	"schedule-api/layout"
+	"schedule-api/logging"
+	"schedule-api/metrics"
 	"schedule-api/middleware"
+	"schedule-api/openapi"
 	"schedule-api/services"
+	"schedule-api/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	log.Println("Start service")
 	// Загружаем .env файл (игнорируем ошибку для продакшн)
 	_ = godotenv.Load()
 
 	// Загружаем конфигурацию
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+	logging.Setup(cfg.Environment)
+	slog.Info("starting service", "environment", cfg.Environment)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingSampleRatio, cfg.Environment)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
+	}
+	defer shutdownTracing(context.Background())
 
-	log.Println("init services")
 	// Инициализируем сервисы
 	minioService, err := services.NewMinIOService(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize MinIO service: %v", err)
+		fatal("failed to initialize MinIO service", err)
 	}
 
 	cacheService := services.NewCacheService(cfg.CacheTTL, 2*cfg.CacheTTL)
+	metrics.RegisterCache(func() metrics.CacheCounters {
+		counters := metrics.CacheCounters{
+			Hits:          make(map[string]uint64),
+			Misses:        make(map[string]uint64),
+			Evictions:     make(map[string]uint64),
+			Invalidations: make(map[string]uint64),
+		}
+		for family, stats := range cacheService.Stats().Families {
+			counters.Hits[family], counters.Misses[family] = stats.Hits, stats.Misses
+			counters.Evictions[family], counters.Invalidations[family] = stats.Evictions, stats.Invalidations
+		}
+		return counters
+	})
+
+	apiKeys, err := services.LoadAPIKeys(context.Background(), cfg, minioService)
+	if err != nil {
+		fatal("failed to load API keys", err)
+	}
+	if cfg.AuthEnabled && len(apiKeys) == 0 && cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		slog.Warn("authentication is enabled but neither API keys nor JWKS are configured, admin endpoints are unreachable")
+	}
+	jwtVerifier, err := services.NewJWTVerifier(cfg)
+	if err != nil {
+		fatal("failed to initialize JWT verifier", err)
+	}
+	authService := services.NewAuthService(apiKeys, jwtVerifier)
+	auditService := services.NewAuditService(minioService, cfg.AuditBucket, cfg.AuditPrefix)
+	diffService := services.NewDiffService(minioService, cfg.DiffBucket, cfg.DiffPrefix)
+	webhookService := services.NewWebhookService(minioService, cfg)
+	if err := webhookService.Load(context.Background()); err != nil {
+		slog.Warn("failed to load webhook subscriptions, will retry on first use", "error", err)
+	}
+	teacherDirectory := services.NewTeacherDirectory(minioService, cfg)
+	if err := teacherDirectory.Load(context.Background()); err != nil {
+		slog.Warn("failed to load teacher directory, will retry on first use", "error", err)
+	}
+	eventBus := services.NewEventBus(cfg.EventLogSize)
+	jobTracker := services.NewJobTracker()
+
+	// Раскладка путей уже проверена в config.Load
+	layouts, err := layout.NewResolver(cfg.FilePathPattern, cfg.UniversityLayouts)
+	if err != nil {
+		fatal("failed to initialize storage layout", err)
+	}
 
-	log.Println("init handlers")
 	// Инициализируем handlers
-	universityHandler := handlers.NewUniversityHandler(minioService, cacheService)
-	courseHandler := handlers.NewCourseHandler(minioService, cacheService)
-	scheduleHandler := handlers.NewScheduleHandler(minioService, cacheService)
-	uploadFileHandler := handlers.NewUploadFileHandler(minioService, cacheService, cfg.SourceBucket, cfg.TargetBucket, cfg.FilePathPattern)
+	universityHandler := handlers.NewUniversityHandler(minioService, cacheService, layouts, cfg.ServicePaths())
+	courseHandler := handlers.NewCourseHandler(minioService, cacheService, layouts)
+	scheduleHandler := handlers.NewScheduleHandler(minioService, cacheService, auditService, diffService, eventBus, layouts)
+	uploadFileHandler := handlers.NewUploadFileHandler(minioService, cacheService, auditService, diffService, webhookService, teacherDirectory, eventBus, jobTracker, cfg.SourceBucket, cfg.TargetBucket, layouts)
+	cacheHandler := handlers.NewCacheHandler(cacheService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, auditService, cfg.Environment == "production")
+	teacherHandler := handlers.NewTeacherHandler(teacherDirectory, auditService)
+	eventHandler := handlers.NewEventHandler(eventBus, cfg.EventHeartbeat)
+	healthHandler := handlers.NewHealthHandler(minioService, cfg.MinIOBucket, cfg.SourceBucket, cfg.TargetBucket)
+
+	apiSpec, err := openapi.Load()
+	if err != nil {
+		fatal("failed to load OpenAPI spec", err)
+	}
 
 	// Настраиваем Gin
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	log.Println("init router")
-	router := gin.New()
-	router.Use(middleware.Logger())
-	router.Use(middleware.CORS())
-	router.Use(gin.Recovery())
+	corsPolicy := middleware.NewCORSPolicy(corsOptions(cfg))
+	readLimiter := middleware.NewRateLimiter("read", cfg.RateLimitRead, cfg.RateLimitReadBurst)
+	presignLimiter := middleware.NewRateLimiter("download", cfg.RateLimitPresign, cfg.RateLimitPresignBurst)
+	adminLimiter := middleware.NewRateLimiter("admin", cfg.RateLimitAdmin, cfg.RateLimitAdminBurst)
+	authFailureLimiter := middleware.NewRateLimiter("authentication", cfg.RateLimitAuthFailures, cfg.RateLimitAuthFailuresBurst)
+
+	router, err := newRouter(routerDeps{
+		university:         universityHandler,
+		course:             courseHandler,
+		schedule:           scheduleHandler,
+		upload:             uploadFileHandler,
+		cache:              cacheHandler,
+		audit:              auditHandler,
+		webhook:            webhookHandler,
+		teacher:            teacherHandler,
+		event:              eventHandler,
+		webSocket:          handlers.NewWebSocketHandler(eventBus, corsPolicy, cfg.EventHeartbeat, cfg.WebSocketMaxSubscriptions),
+		health:             healthHandler,
+		spec:               apiSpec,
+		auth:               authService,
+		authEnabled:        cfg.AuthEnabled,
+		cors:               corsPolicy,
+		trustedProxies:     cfg.TrustedProxies,
+		readLimiter:        readLimiter,
+		presignLimiter:     presignLimiter,
+		adminLimiter:       adminLimiter,
+		authFailureLimiter: authFailureLimiter,
+	})
+	if err != nil {
+		fatal("failed to initialize router", err)
+	}
+
+	// Каждый маршрут должен быть описан в openapi/openapi.yaml: при разработке сервис
+	// не запускается с неописанным маршрутом, в продакшне расхождение только пишется в лог
+	if undocumented := apiSpec.Undocumented(router.Routes()); len(undocumented) > 0 {
+		if cfg.Environment != "production" {
+			fatal("routes are missing from the OpenAPI spec", errors.New(strings.Join(undocumented, ", ")))
+		}
+		slog.Warn("routes are missing from the OpenAPI spec", "routes", undocumented)
+	}
+
+	// Запускаем сервер
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// Потоки событий сами не завершаются — закрываем их в начале остановки
+	server.RegisterOnShutdown(eventBus.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Перезагрузка настроек, не связанных с подключениями, по SIGHUP или изменению CONFIG_FILE
+	go config.Watch(ctx, cfg.ConfigFile, 10*time.Second, func(next *config.Config) {
+		if changed := cfg.RestartRequired(next); len(changed) > 0 {
+			slog.Warn("some configuration changes require a restart and were not applied", "settings", changed)
+		}
+
+		cacheService.SetDefaultTTL(next.CacheTTL)
+		minioService.SetPresignedURLTTL(next.PresignedURLTTL)
+		readLimiter.SetLimit(next.RateLimitRead, next.RateLimitReadBurst)
+		presignLimiter.SetLimit(next.RateLimitPresign, next.RateLimitPresignBurst)
+		adminLimiter.SetLimit(next.RateLimitAdmin, next.RateLimitAdminBurst)
+		authFailureLimiter.SetLimit(next.RateLimitAuthFailures, next.RateLimitAuthFailuresBurst)
+		corsPolicy.Update(corsOptions(next))
+	})
 
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		// Health check
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"status": "ok",
-				"time":   time.Now(),
-			})
-		})
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "port", cfg.ServerPort)
+		serverErr <- server.ListenAndServe()
+	}()
 
-		// Universities
-		api.GET("/universities", universityHandler.GetUniversities)
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("failed to start server", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
 
-		// Courses
-		api.GET("/universities/:university/courses", courseHandler.GetCourses)
+	shutdown(server, jobTracker, webhookService, cfg.ShutdownTimeout)
+}
 
-		// Schedule types
-		api.GET("/universities/:university/courses/:course/types", scheduleHandler.GetScheduleTypes)
+// shutdown перестаёт принимать новые запросы и ждёт завершения текущих запросов и заданий обработки.
+// По истечении timeout оставшиеся соединения закрываются, а прерванные задания пишутся в лог
+func shutdown(server *http.Server, jobs *services.JobTracker, webhooks *services.WebhookService, timeout time.Duration) {
+	slog.Info("shutting down", "timeout", timeout, "active_jobs", len(jobs.Active()))
 
-		// Schedule files
-		api.GET("/universities/:university/courses/:course/types/:type/files", scheduleHandler.GetScheduleFiles)
+	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
 
-		// Download presigned URL
-		api.GET("/universities/:university/courses/:course/types/:type/files/:filename/download", scheduleHandler.GetPresignedDownloadURL)
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("in-flight requests did not finish in time", "error", err)
+	}
 
-		// Cache management
-		api.POST("/cache/invalidate", scheduleHandler.InvalidateCache)
+	if !jobs.Wait(time.Until(deadline)) {
+		for _, job := range jobs.Active() {
+			slog.Error("processing job interrupted",
+				"job_id", job.ID,
+				"request_id", job.RequestID,
+				"actor", job.Actor,
+				"started_at", job.StartedAt,
+				"processed", job.Processed,
+				"total", job.Total,
+				"current_file", job.CurrentFile,
+			)
+		}
+	}
 
-		// File processing
-		api.POST("/files_uploaded", uploadFileHandler.ProcessFile)
+	// Новые уведомления не принимаются, ожидающие повтора доставки уходят в dead-letter
+	if !webhooks.Close(time.Until(deadline)) {
+		slog.Warn("webhook deliveries did not finish in time")
 	}
 
-	// Запускаем сервер
-	log.Printf("Starting server on port %s", cfg.ServerPort)
-	if err := router.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Закрываем оставшиеся соединения — это отменяет context незавершённых запросов
+	if err := server.Close(); err != nil {
+		slog.Warn("failed to close server", "error", err)
 	}
+	slog.Info("server stopped")
+}
+
+func corsOptions(cfg *config.Config) middleware.CORSOptions {
+	return middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		ExposedHeaders:   cfg.CORSExposedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
+
+// fatal пишет ошибку запуска в лог и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+
+	"schedule-api/handlers"
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/openapi"
+	"schedule-api/services"
+	"schedule-api/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// routerDeps — обработчики и middleware, из которых собирается маршрутизатор
+type routerDeps struct {
+	university *handlers.UniversityHandler
+	course     *handlers.CourseHandler
+	schedule   *handlers.ScheduleHandler
+	upload     *handlers.UploadFileHandler
+	cache      *handlers.CacheHandler
+	audit      *handlers.AuditHandler
+	webhook    *handlers.WebhookHandler
+	teacher    *handlers.TeacherHandler
+	event      *handlers.EventHandler
+	webSocket  *handlers.WebSocketHandler
+	health     *handlers.HealthHandler
+	spec       *openapi.Spec
+
+	auth               *services.AuthService
+	authEnabled        bool
+	cors               *middleware.CORSPolicy
+	trustedProxies     []string
+	readLimiter        *middleware.RateLimiter
+	presignLimiter     *middleware.RateLimiter
+	adminLimiter       *middleware.RateLimiter
+	authFailureLimiter *middleware.RateLimiter
+}
+
+// newRouter регистрирует middleware и все маршруты сервиса
+func newRouter(d routerDeps) (*gin.Engine, error) {
+	router := gin.New()
+	// По умолчанию gin доверяет X-Forwarded-For от любого клиента, и адрес в ограничении
+	// частоты и журнале аудита можно было бы подменить
+	if err := router.SetTrustedProxies(d.trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Logger())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.CORS(d.cors))
+	router.Use(middleware.Recovery())
+	router.Use(middleware.Authenticate(d.auth, d.authEnabled, d.authFailureLimiter))
+
+	// Prometheus
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Probes: liveness не зависит от внешних сервисов, readiness проверяет MinIO и бакеты
+	router.GET("/healthz", d.health.Liveness)
+	router.GET("/readyz", d.health.Readiness)
+
+	// API routes
+	api := router.Group("/api/v1")
+	{
+		// Health check (совместимость, эквивалент /healthz)
+		api.GET("/health", d.health.Liveness)
+
+		// Документация
+		api.GET("/openapi.json", d.spec.ServeJSON)
+		api.GET("/docs", d.spec.ServeUI)
+
+		read := api.Group("", middleware.RateLimit(d.readLimiter))
+		{
+			// Universities
+			read.GET("/universities", d.university.GetUniversities)
+
+			// Courses
+			read.GET("/universities/:university/courses", d.course.GetCourses)
+
+			// Schedule types
+			read.GET("/universities/:university/courses/:course/types", d.schedule.GetScheduleTypes)
+
+			// Schedule files
+			read.GET("/universities/:university/courses/:course/types/:type/files", d.schedule.GetScheduleFiles)
+
+			// File version history
+			read.GET("/universities/:university/courses/:course/types/:type/files/:filename/versions", d.schedule.GetFileVersions)
+			read.GET("/universities/:university/courses/:course/types/:type/files/:filename/versions/:version", d.schedule.GetFileVersionContent)
+
+			// Group exam session
+			read.GET("/universities/:university/courses/:course/types/:type/files/:filename/groups/:group/exams", d.schedule.GetGroupExamSession)
+
+			// Teacher directory
+			read.GET("/teachers", d.teacher.GetTeachers)
+			read.GET("/teachers/:id", d.teacher.GetTeacher)
+
+			// Live updates (Server-Sent Events)
+			read.GET("/events", d.event.Stream)
+			read.GET("/ws", d.webSocket.Connect)
+
+			// Schedule diff
+			read.GET("/universities/:university/courses/:course/types/:type/files/:filename/diff", d.schedule.GetFileDiff)
+		}
+
+		// Download presigned URL
+		api.GET("/universities/:university/courses/:course/types/:type/files/:filename/download", middleware.RateLimit(d.presignLimiter), d.schedule.GetPresignedDownloadURL)
+
+		// Действия над всеми университетами сразу недоступны администраторам одного университета
+		allUniversities := middleware.RequireAllUniversities()
+
+		admin := api.Group("", middleware.RateLimit(d.adminLimiter))
+		{
+			// Cache management
+			admin.POST("/cache/invalidate", middleware.RequireRole(models.RoleAdmin), allUniversities, d.schedule.InvalidateCache)
+			admin.GET("/cache/stats", middleware.RequireRole(models.RoleAdmin), allUniversities, d.cache.GetStats)
+			admin.GET("/cache/keys", middleware.RequireRole(models.RoleAdmin), allUniversities, d.cache.GetKeys)
+
+			// Audit log
+			admin.GET("/audit", middleware.RequireRole(models.RoleAdmin), d.audit.GetAuditLog)
+
+			// Webhooks
+			admin.GET("/webhooks", middleware.RequireRole(models.RoleAdmin), d.webhook.GetSubscriptions)
+			admin.POST("/webhooks", middleware.RequireRole(models.RoleAdmin), d.webhook.CreateSubscription)
+			admin.DELETE("/webhooks/:id", middleware.RequireRole(models.RoleAdmin), d.webhook.DeleteSubscription)
+			admin.GET("/webhooks/deliveries", middleware.RequireRole(models.RoleAdmin), allUniversities, d.webhook.GetDeliveries)
+			admin.GET("/webhooks/dead-letters", middleware.RequireRole(models.RoleAdmin), allUniversities, d.webhook.GetDeadLetters)
+			admin.POST("/webhooks/dead-letters/:id/retry", middleware.RequireRole(models.RoleAdmin), allUniversities, d.webhook.RetryDeadLetter)
+
+			// Teacher directory mapping
+			admin.PUT("/teachers/mapping", middleware.RequireRole(models.RoleAdmin), allUniversities, d.teacher.SetMapping)
+
+			// File processing
+			admin.POST("/files_uploaded", middleware.RequireRole(models.RoleUploader), d.upload.ProcessFile)
+			admin.POST("/universities/:university/courses/:course/types/:type/files/:filename/versions/:version/restore", middleware.RequireRole(models.RoleUploader), d.schedule.RestoreFileVersion)
+		}
+	}
+
+	return router, nil
+}
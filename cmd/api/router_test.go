@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"schedule-api/handlers"
+	"schedule-api/middleware"
+	"schedule-api/openapi"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Каждый зарегистрированный маршрут должен быть описан в openapi/openapi.yaml.
+// Обработчики при регистрации не вызываются, поэтому сервисы им не нужны
+func TestRoutesDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load OpenAPI spec: %v", err)
+	}
+	cors := middleware.NewCORSPolicy(middleware.CORSOptions{AllowedOrigins: []string{"*"}})
+
+	router, err := newRouter(routerDeps{
+		university:         &handlers.UniversityHandler{},
+		course:             &handlers.CourseHandler{},
+		schedule:           &handlers.ScheduleHandler{},
+		upload:             &handlers.UploadFileHandler{},
+		cache:              &handlers.CacheHandler{},
+		audit:              &handlers.AuditHandler{},
+		webhook:            &handlers.WebhookHandler{},
+		teacher:            &handlers.TeacherHandler{},
+		event:              &handlers.EventHandler{},
+		webSocket:          handlers.NewWebSocketHandler(services.NewEventBus(1), cors, 0, 1),
+		health:             &handlers.HealthHandler{},
+		spec:               spec,
+		cors:               cors,
+		readLimiter:        middleware.NewRateLimiter("read", 0, 0),
+		presignLimiter:     middleware.NewRateLimiter("download", 0, 0),
+		adminLimiter:       middleware.NewRateLimiter("admin", 0, 0),
+		authFailureLimiter: middleware.NewRateLimiter("authentication", 0, 0),
+	})
+	if err != nil {
+		t.Fatalf("failed to build router: %v", err)
+	}
+
+	if undocumented := spec.Undocumented(router.Routes()); len(undocumented) > 0 {
+		t.Errorf("routes are missing from the OpenAPI spec: %v", undocumented)
+	}
+	if len(router.Routes()) == 0 {
+		t.Error("router has no routes")
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,33 +20,186 @@ type Config struct {
 	Environment     string
 	SourceBucket    string // Бакет для исходных XLSX файлов
 	TargetBucket    string // Бакет для обработанных JSON файлов
-	FilePathPattern string // Паттерн пути к файлам
+	FilePathPattern string // Паттерн пути к файлам: {university}/{course}/{type}/{file} или четыре %s
+	AuthEnabled     bool   // Требовать API-ключ для административных эндпоинтов
+	APIKeysFile     string // JSON-файл с хешами API-ключей
+	APIKeysObject   string // Объект в MinIOBucket с хешами API-ключей
+
+	// Раскладка путей для отдельных университетов (университет -> шаблон), остальные используют FilePathPattern
+	UniversityLayouts map[string]string
+
+	// SSO: проверка JWT по JWKS (файл или URL)
+	JWKSFile             string
+	JWKSURL              string
+	JWTIssuer            string
+	JWTAudience          string
+	JWTRoleClaim         string // Claim с ролями, допускается путь через точку (realm_access.roles)
+	JWTUniversitiesClaim string // Claim со списком доступных университетов
+	JWTRoleMap           string // Соответствие значений claim ролям: "schedule-admins=admin,dean-office=uploader"
+
+	// Ограничение частоты запросов (в минуту на клиента, 0 — без ограничения)
+	RateLimitRead         int
+	RateLimitReadBurst    int
+	RateLimitPresign      int
+	RateLimitPresignBurst int
+	RateLimitAdmin        int
+	RateLimitAdminBurst   int
+
+	// Неудачных попыток аутентификации в минуту с одного IP (0 — без ограничения)
+	RateLimitAuthFailures      int
+	RateLimitAuthFailuresBurst int
+
+	// Прокси, которым разрешено передавать адрес клиента в X-Forwarded-For (IP или CIDR).
+	// Пустой список — адрес клиента всегда берётся из соединения
+	TrustedProxies []string
+
+	// CORS
+	CORSAllowedOrigins   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	AuditBucket string // Бакет журнала аудита
+	AuditPrefix string // Префикс объектов журнала аудита
+
+	DiffBucket string // Бакет для различий между версиями расписаний
+	DiffPrefix string // Префикс объектов различий
+
+	// Исходящие webhook'и
+	WebhooksObject     string        // Объект в MinIOBucket с подписками
+	WebhookTimeout     time.Duration // Таймаут одной попытки доставки
+	WebhookMaxAttempts int           // Попыток до переноса в dead-letter
+	WebhookRetryBase   time.Duration // Пауза перед первым повтором, далее удваивается
+	WebhookConcurrency int           // Одновременных запросов к подписчикам
+
+	TeachersObject string // Объект в MinIOBucket со справочником преподавателей
+
+	EventLogSize   int           // Сколько последних событий хранится для возобновления потока
+	EventHeartbeat time.Duration // Интервал heartbeat в потоке событий и WebSocket
+
+	WebSocketMaxSubscriptions int // Групп и преподавателей на одно WebSocket-соединение
+
+	TracingExporter    string  // none, stdout или otlp
+	TracingSampleRatio float64 // Доля трассируемых запросов без входящего trace context
+
+	ShutdownTimeout time.Duration // Время на завершение текущих запросов и заданий при остановке
+
+	ConfigFile string // Путь к YAML-файлу конфигурации, если он задан
 }
 
-func Load() *Config {
-	cacheMinutes, _ := strconv.Atoi(getEnv("CACHE_TTL_MINUTES", "10"))
-	presignedMinutes, _ := strconv.Atoi(getEnv("PRESIGNED_URL_TTL_MINUTES", "15"))
-	useSSL, _ := strconv.ParseBool(getEnv("MINIO_USE_SSL", "false"))
-
-	return &Config{
-		ServerPort:      getEnv("SERVER_PORT", "8080"),
-		MinIOEndpoint:   getEnv("MINIO_ENDPOINT", "minio:9000"),
-		MinIOAccessKey:  getEnv("MINIO_ACCESS_KEY", "minioadmin"),
-		MinIOSecretKey:  getEnv("MINIO_SECRET_KEY", "minioadmin"),
-		MinIOBucket:     getEnv("MINIO_BUCKET", "university-schedules"),
-		MinIOUseSSL:     useSSL,
-		CacheTTL:        time.Duration(cacheMinutes) * time.Minute,
-		PresignedURLTTL: time.Duration(presignedMinutes) * time.Minute,
-		Environment:     getEnv("ENVIRONMENT", "development"),
-		SourceBucket:    getEnv("SOURCE_BUCKET", "file-upload"),
-		TargetBucket:    getEnv("TARGET_BUCKET", "university-schedules"),
-		FilePathPattern: getEnv("FILE_PATH_PATTERN", "universities/%s/courses/%s/types/%s/files/%s"),
+// Load читает конфигурацию из переменных окружения и, если задан CONFIG_FILE, из YAML-файла.
+// Переменные окружения имеют приоритет над файлом. Некорректные значения не заменяются
+// молча нулями: Load возвращает ошибку со списком всех проблем
+func Load() (*Config, error) {
+	src, err := newSource(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		ServerPort:      src.str("SERVER_PORT", "8080"),
+		MinIOEndpoint:   src.str("MINIO_ENDPOINT", "minio:9000"),
+		MinIOAccessKey:  src.str("MINIO_ACCESS_KEY", "minioadmin"),
+		MinIOSecretKey:  src.str("MINIO_SECRET_KEY", "minioadmin"),
+		MinIOBucket:     src.str("MINIO_BUCKET", "university-schedules"),
+		MinIOUseSSL:     src.bool("MINIO_USE_SSL", false),
+		CacheTTL:        src.duration("CACHE_TTL_MINUTES", 10, time.Minute),
+		PresignedURLTTL: src.duration("PRESIGNED_URL_TTL_MINUTES", 15, time.Minute),
+		Environment:     src.str("ENVIRONMENT", "development"),
+		SourceBucket:    src.str("SOURCE_BUCKET", "file-upload"),
+		TargetBucket:    src.str("TARGET_BUCKET", "university-schedules"),
+		FilePathPattern: src.str("FILE_PATH_PATTERN", "universities/%s/courses/%s/types/%s/files/%s"),
+		AuthEnabled:     src.bool("AUTH_ENABLED", true),
+		APIKeysFile:     src.str("API_KEYS_FILE", ""),
+		APIKeysObject:   src.str("API_KEYS_OBJECT", ""),
+
+		UniversityLayouts: src.mapping("UNIVERSITY_LAYOUTS"),
+
+		JWKSFile:             src.str("JWKS_FILE", ""),
+		JWKSURL:              src.str("JWKS_URL", ""),
+		JWTIssuer:            src.str("JWT_ISSUER", ""),
+		JWTAudience:          src.str("JWT_AUDIENCE", ""),
+		JWTRoleClaim:         src.str("JWT_ROLE_CLAIM", "roles"),
+		JWTUniversitiesClaim: src.str("JWT_UNIVERSITIES_CLAIM", "universities"),
+		JWTRoleMap:           src.str("JWT_ROLE_MAP", ""),
+
+		RateLimitRead:         src.int("RATE_LIMIT_READ_PER_MINUTE", 300),
+		RateLimitReadBurst:    src.int("RATE_LIMIT_READ_BURST", 60),
+		RateLimitPresign:      src.int("RATE_LIMIT_PRESIGN_PER_MINUTE", 30),
+		RateLimitPresignBurst: src.int("RATE_LIMIT_PRESIGN_BURST", 10),
+		RateLimitAdmin:        src.int("RATE_LIMIT_ADMIN_PER_MINUTE", 20),
+		RateLimitAdminBurst:   src.int("RATE_LIMIT_ADMIN_BURST", 5),
+
+		RateLimitAuthFailures:      src.int("RATE_LIMIT_AUTH_FAILURES_PER_MINUTE", 10),
+		RateLimitAuthFailuresBurst: src.int("RATE_LIMIT_AUTH_FAILURES_BURST", 10),
+
+		TrustedProxies: splitList(src.str("TRUSTED_PROXIES", "")),
+
+		CORSAllowedOrigins:   splitList(src.str("CORS_ALLOWED_ORIGINS", "*")),
+		CORSExposedHeaders:   splitList(src.str("CORS_EXPOSED_HEADERS", "X-Request-ID,X-RateLimit-Limit,X-RateLimit-Remaining,Retry-After")),
+		CORSAllowCredentials: src.bool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           src.duration("CORS_MAX_AGE_SECONDS", 600, time.Second),
+
+		AuditBucket: src.str("AUDIT_BUCKET", src.str("MINIO_BUCKET", "university-schedules")),
+		AuditPrefix: src.str("AUDIT_PREFIX", "audit/"),
+
+		DiffBucket: src.str("DIFF_BUCKET", src.str("TARGET_BUCKET", "university-schedules")),
+		DiffPrefix: src.str("DIFF_PREFIX", "diffs/"),
+
+		WebhooksObject:     src.str("WEBHOOKS_OBJECT", "webhooks/subscriptions.json"),
+		WebhookTimeout:     src.duration("WEBHOOK_TIMEOUT_SECONDS", 10, time.Second),
+		WebhookMaxAttempts: src.int("WEBHOOK_MAX_ATTEMPTS", 6),
+		WebhookRetryBase:   src.duration("WEBHOOK_RETRY_BASE_SECONDS", 5, time.Second),
+		WebhookConcurrency: src.int("WEBHOOK_CONCURRENCY", 4),
+
+		TeachersObject: src.str("TEACHERS_OBJECT", "teachers/directory.json"),
+
+		EventLogSize:   src.int("EVENT_LOG_SIZE", 1000),
+		EventHeartbeat: src.duration("EVENT_HEARTBEAT_SECONDS", 15, time.Second),
+
+		WebSocketMaxSubscriptions: src.int("WEBSOCKET_MAX_SUBSCRIPTIONS", 50),
+
+		TracingExporter:    src.str("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: src.float("TRACING_SAMPLE_RATIO", 1),
+
+		ShutdownTimeout: src.duration("SHUTDOWN_TIMEOUT_SECONDS", 30, time.Second),
+
+		ConfigFile: src.path,
+	}
+
+	if err := errors.Join(append(src.errs, cfg.Validate())...); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// ServicePaths возвращает объекты и префиксы TargetBucket, в которых сервис хранит свои данные
+// (журнал аудита, различия, подписки, справочники), чтобы листинг не принимал их за расписания
+func (c *Config) ServicePaths() []string {
+	var paths []string
+	if c.AuditBucket == c.TargetBucket {
+		paths = append(paths, c.AuditPrefix)
+	}
+	if c.DiffBucket == c.TargetBucket {
+		paths = append(paths, c.DiffPrefix)
+	}
+	if c.MinIOBucket == c.TargetBucket {
+		for _, object := range []string{c.WebhooksObject, c.TeachersObject, c.APIKeysObject} {
+			if object != "" {
+				paths = append(paths, object)
+			}
+		}
 	}
+	return paths
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// splitList разбирает список значений через запятую, пропуская пустые
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return defaultValue
+	return items
 }
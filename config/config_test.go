@@ -0,0 +1,244 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadTest читает конфигурацию из файла с содержимым yaml (пустая строка — без файла)
+func loadTest(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	path := ""
+	if yaml != "" {
+		path = filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CONFIG_FILE", path)
+	return Load()
+}
+
+func TestValidate(t *testing.T) {
+	base, err := loadTest(t, "")
+	if err != nil {
+		t.Fatalf("default configuration: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{name: "port is not a number", modify: func(c *Config) { c.ServerPort = "http" }, wantErr: "SERVER_PORT"},
+		{name: "port out of range", modify: func(c *Config) { c.ServerPort = "70000" }, wantErr: "SERVER_PORT"},
+		{name: "no endpoint", modify: func(c *Config) { c.MinIOEndpoint = "" }, wantErr: "MINIO_ENDPOINT is required"},
+		{name: "no bucket", modify: func(c *Config) { c.MinIOBucket = "" }, wantErr: "MINIO_BUCKET is required"},
+		{name: "no source bucket", modify: func(c *Config) { c.SourceBucket = "" }, wantErr: "SOURCE_BUCKET is required"},
+		{name: "no target bucket", modify: func(c *Config) { c.TargetBucket = "" }, wantErr: "TARGET_BUCKET is required"},
+		{name: "bad path pattern", modify: func(c *Config) { c.FilePathPattern = "{university}/{file}" }, wantErr: "FILE_PATH_PATTERN"},
+		{
+			name:    "bad university layout",
+			modify:  func(c *Config) { c.UniversityLayouts = map[string]string{"kgu": "{course}"} },
+			wantErr: "UNIVERSITY_LAYOUTS",
+		},
+		{name: "zero cache ttl", modify: func(c *Config) { c.CacheTTL = 0 }, wantErr: "CACHE_TTL_MINUTES"},
+		{name: "presigned ttl too long", modify: func(c *Config) { c.PresignedURLTTL = 8 * 24 * time.Hour }, wantErr: "PRESIGNED_URL_TTL_MINUTES"},
+		{name: "zero shutdown timeout", modify: func(c *Config) { c.ShutdownTimeout = 0 }, wantErr: "SHUTDOWN_TIMEOUT_SECONDS"},
+		{name: "negative cors max age", modify: func(c *Config) { c.CORSMaxAge = -time.Second }, wantErr: "CORS_MAX_AGE_SECONDS"},
+		{name: "no webhooks object", modify: func(c *Config) { c.WebhooksObject = "" }, wantErr: "WEBHOOKS_OBJECT"},
+		{name: "zero webhook timeout", modify: func(c *Config) { c.WebhookTimeout = 0 }, wantErr: "WEBHOOK_TIMEOUT_SECONDS"},
+		{name: "zero webhook attempts", modify: func(c *Config) { c.WebhookMaxAttempts = 0 }, wantErr: "WEBHOOK_MAX_ATTEMPTS"},
+		{name: "zero webhook retry base", modify: func(c *Config) { c.WebhookRetryBase = 0 }, wantErr: "WEBHOOK_RETRY_BASE_SECONDS"},
+		{name: "zero webhook concurrency", modify: func(c *Config) { c.WebhookConcurrency = 0 }, wantErr: "WEBHOOK_CONCURRENCY"},
+		{name: "no teachers object", modify: func(c *Config) { c.TeachersObject = "" }, wantErr: "TEACHERS_OBJECT"},
+		{name: "zero event log", modify: func(c *Config) { c.EventLogSize = 0 }, wantErr: "EVENT_LOG_SIZE"},
+		{name: "zero heartbeat", modify: func(c *Config) { c.EventHeartbeat = 0 }, wantErr: "EVENT_HEARTBEAT_SECONDS"},
+		{name: "zero websocket subscriptions", modify: func(c *Config) { c.WebSocketMaxSubscriptions = 0 }, wantErr: "WEBSOCKET_MAX_SUBSCRIPTIONS"},
+		{name: "negative rate limit", modify: func(c *Config) { c.RateLimitPresign = -1 }, wantErr: "RATE_LIMIT_PRESIGN_PER_MINUTE"},
+		{name: "negative auth failures burst", modify: func(c *Config) { c.RateLimitAuthFailuresBurst = -1 }, wantErr: "RATE_LIMIT_AUTH_FAILURES_BURST"},
+		{name: "zero rate limit", modify: func(c *Config) { c.RateLimitRead = 0 }},
+		{
+			name: "any origin with credentials",
+			modify: func(c *Config) {
+				c.CORSAllowedOrigins = []string{"*"}
+				c.CORSAllowCredentials = true
+			},
+			wantErr: `"*" cannot be combined with CORS_ALLOW_CREDENTIALS`,
+		},
+		{
+			name: "listed origins with credentials",
+			modify: func(c *Config) {
+				c.CORSAllowedOrigins = []string{"https://schedule.example.edu", "https://*.example.edu:8443"}
+				c.CORSAllowCredentials = true
+			},
+		},
+		{name: "origin without scheme", modify: func(c *Config) { c.CORSAllowedOrigins = []string{"example.edu"} }, wantErr: "CORS_ALLOWED_ORIGINS"},
+		{name: "origin with path", modify: func(c *Config) { c.CORSAllowedOrigins = []string{"https://example.edu/app"} }, wantErr: "CORS_ALLOWED_ORIGINS"},
+		{name: "trusted proxy cidr", modify: func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8", "::1"} }},
+		{name: "bad trusted proxy", modify: func(c *Config) { c.TrustedProxies = []string{"proxy.local"} }, wantErr: "TRUSTED_PROXIES"},
+		{name: "unknown tracing exporter", modify: func(c *Config) { c.TracingExporter = "jaeger" }, wantErr: "TRACING_EXPORTER"},
+		{name: "sample ratio above one", modify: func(c *Config) { c.TracingSampleRatio = 1.5 }, wantErr: "TRACING_SAMPLE_RATIO"},
+		{
+			name: "jwks file and url",
+			modify: func(c *Config) {
+				c.JWKSFile = "jwks.json"
+				c.JWKSURL = "https://sso.example.edu/jwks"
+			},
+			wantErr: "JWKS_FILE and JWKS_URL are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *base
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg, err := loadTest(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MinIOEndpoint = ""
+	cfg.CacheTTL = 0
+	cfg.TracingExporter = "jaeger"
+
+	err = cfg.Validate()
+	for _, want := range []string{"MINIO_ENDPOINT", "CACHE_TTL_MINUTES", "TRACING_EXPORTER"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want error containing %q", err, want)
+		}
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9100")
+	t.Setenv("CACHE_TTL_MINUTES", "") // Пустая переменная не перекрывает файл
+
+	cfg, err := loadTest(t, `
+server_port: 9000
+CACHE_TTL_MINUTES: 5
+cors_allowed_origins:
+  - https://schedule.example.edu
+  - https://*.example.edu
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServerPort != "9100" {
+		t.Errorf("ServerPort = %q, want the environment value 9100", cfg.ServerPort)
+	}
+	if cfg.CacheTTL != 5*time.Minute {
+		t.Errorf("CacheTTL = %s, want 5m from the file", cfg.CacheTTL)
+	}
+	if want := []string{"https://schedule.example.edu", "https://*.example.edu"}; !slices.Equal(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("CORSAllowedOrigins = %v, want %v", cfg.CORSAllowedOrigins, want)
+	}
+	if cfg.PresignedURLTTL != 15*time.Minute {
+		t.Errorf("PresignedURLTTL = %s, want the default 15m", cfg.PresignedURLTTL)
+	}
+	if cfg.ConfigFile == "" {
+		t.Error("ConfigFile is empty")
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		yaml    string
+		wantErr string
+	}{
+		{name: "not an integer in file", yaml: "cache_ttl_minutes: soon\n", wantErr: `CACHE_TTL_MINUTES: "soon" is not an integer`},
+		{name: "not a boolean in env", env: map[string]string{"AUTH_ENABLED": "maybe"}, wantErr: "AUTH_ENABLED"},
+		{name: "env value is validated", env: map[string]string{"SERVER_PORT": "0"}, yaml: "server_port: 9000\n", wantErr: "SERVER_PORT"},
+		{name: "nested key", yaml: "cors:\n  max_age: 10\n", wantErr: "must be a scalar or a list"},
+		{name: "bad mapping", env: map[string]string{"UNIVERSITY_LAYOUTS": "kgu"}, wantErr: "UNIVERSITY_LAYOUTS"},
+		{
+			name:    "credentials with any origin from file",
+			yaml:    "cors_allowed_origins: \"*\"\ncors_allow_credentials: true\n",
+			wantErr: "CORS_ALLOW_CREDENTIALS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := loadTest(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	base, err := loadTest(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{name: "nothing changed", modify: func(c *Config) {}},
+		{
+			name: "reloadable settings",
+			modify: func(c *Config) {
+				c.CacheTTL = time.Minute
+				c.RateLimitRead = 1
+				c.CORSAllowedOrigins = []string{"https://schedule.example.edu"}
+				c.CORSAllowCredentials = true
+				c.CORSMaxAge = time.Hour
+			},
+		},
+		{name: "port", modify: func(c *Config) { c.ServerPort = "9000" }, want: []string{"ServerPort"}},
+		{
+			name: "connections and auth",
+			modify: func(c *Config) {
+				c.MinIOEndpoint = "storage:9000"
+				c.TargetBucket = "other"
+				c.JWKSURL = "https://sso.example.edu/jwks"
+				c.CacheTTL = time.Minute
+			},
+			want: []string{"MinIOEndpoint", "TargetBucket", "JWKSURL"},
+		},
+		{
+			name: "slice and map fields",
+			modify: func(c *Config) {
+				c.TrustedProxies = []string{"10.0.0.1"}
+				c.UniversityLayouts = map[string]string{"kgu": "x"}
+			},
+			want: []string{"UniversityLayouts", "TrustedProxies"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := *base
+			tt.modify(&next)
+			if got := base.RestartRequired(&next); !slices.Equal(got, tt.want) {
+				t.Errorf("RestartRequired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+)
+
+// reloadableFields — настройки, которые применяются без перезапуска.
+// Подключения (MinIO, бакеты, порт, аутентификация, трассировка) меняются только рестартом
+var reloadableFields = map[string]bool{
+	"CacheTTL":                   true,
+	"PresignedURLTTL":            true,
+	"RateLimitRead":              true,
+	"RateLimitReadBurst":         true,
+	"RateLimitPresign":           true,
+	"RateLimitPresignBurst":      true,
+	"RateLimitAdmin":             true,
+	"RateLimitAdminBurst":        true,
+	"RateLimitAuthFailures":      true,
+	"RateLimitAuthFailuresBurst": true,
+	"CORSAllowedOrigins":         true,
+	"CORSExposedHeaders":         true,
+	"CORSAllowCredentials":       true,
+	"CORSMaxAge":                 true,
+}
+
+// RestartRequired возвращает имена изменившихся настроек, которые нельзя применить на лету
+func (c *Config) RestartRequired(next *Config) []string {
+	current := reflect.ValueOf(c).Elem()
+	updated := reflect.ValueOf(next).Elem()
+
+	var changed []string
+	for i := 0; i < current.NumField(); i++ {
+		name := current.Type().Field(i).Name
+		if reloadableFields[name] {
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// Watch перечитывает конфигурацию по SIGHUP и при изменении файла конфигурации
+// (проверяется раз в interval) и передаёт новую конфигурацию в onReload.
+// Некорректная конфигурация отклоняется целиком, продолжает действовать прежняя
+func Watch(ctx context.Context, path string, interval time.Duration, onReload func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	lastModified := modTime(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	reload := func(reason string) {
+		cfg, err := Load()
+		if err != nil {
+			slog.Error("configuration reload rejected", "reason", reason, "error", err)
+			return
+		}
+		slog.Info("configuration reloaded", "reason", reason)
+		onReload(cfg)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			lastModified = modTime(path)
+			reload("SIGHUP")
+		case <-ticker.C:
+			if path == "" {
+				continue
+			}
+			if modified := modTime(path); !modified.Equal(lastModified) {
+				lastModified = modified
+				reload("file changed")
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
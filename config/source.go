@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// source — значения конфигурации: переменные окружения поверх YAML-файла.
+// Ключи файла совпадают с именами переменных окружения в любом регистре:
+//
+//	cache_ttl_minutes: 5
+//	cors_allowed_origins:
+//	  - https://schedule.example.edu
+//	  - https://*.example.edu
+type source struct {
+	path string
+	file map[string]string
+	errs []error
+}
+
+func newSource(path string) (*source, error) {
+	src := &source{path: path, file: make(map[string]string)}
+	if path == "" {
+		return src, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			src.file[strings.ToUpper(key)] = strings.Join(items, ",")
+		case map[string]interface{}:
+			return nil, fmt.Errorf("config file %s: key %q must be a scalar or a list", path, key)
+		default:
+			src.file[strings.ToUpper(key)] = fmt.Sprint(v)
+		}
+	}
+	return src, nil
+}
+
+func (s *source) str(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value, ok := s.file[key]; ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (s *source) int(key string, defaultValue int) int {
+	raw := s.str(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %q is not an integer", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+func (s *source) float(key string, defaultValue float64) float64 {
+	raw := s.str(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %q is not a number", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+func (s *source) bool(key string, defaultValue bool) bool {
+	raw := s.str(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %q is not a boolean", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+// mapping читает список пар "ключ=значение" через запятую
+func (s *source) mapping(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range splitList(s.str(key, "")) {
+		name, value, found := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !found || name == "" || value == "" {
+			s.errs = append(s.errs, fmt.Errorf("%s: %q must look like key=value", key, pair))
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// duration читает целое число единиц unit (минут, секунд)
+func (s *source) duration(key string, defaultValue int, unit time.Duration) time.Duration {
+	return time.Duration(s.int(key, defaultValue)) * unit
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"schedule-api/layout"
+)
+
+// Validate проверяет значения конфигурации и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	port, err := strconv.Atoi(c.ServerPort)
+	check(err == nil && port > 0 && port < 65536, "SERVER_PORT: %q is not a valid port", c.ServerPort)
+	check(c.MinIOEndpoint != "", "MINIO_ENDPOINT is required")
+	check(c.MinIOBucket != "", "MINIO_BUCKET is required")
+	check(c.SourceBucket != "", "SOURCE_BUCKET is required")
+	check(c.TargetBucket != "", "TARGET_BUCKET is required")
+	if _, err := layout.NewResolver(c.FilePathPattern, c.UniversityLayouts); err != nil {
+		errs = append(errs, fmt.Errorf("FILE_PATH_PATTERN/UNIVERSITY_LAYOUTS: %w", err))
+	}
+
+	check(c.CacheTTL > 0, "CACHE_TTL_MINUTES must be positive")
+	check(c.PresignedURLTTL > 0 && c.PresignedURLTTL <= 7*24*time.Hour, "PRESIGNED_URL_TTL_MINUTES must be between 1 minute and 7 days")
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT_SECONDS must be positive")
+	check(c.CORSMaxAge >= 0, "CORS_MAX_AGE_SECONDS must not be negative")
+	check(c.WebhooksObject != "", "WEBHOOKS_OBJECT is required")
+	check(c.WebhookTimeout > 0, "WEBHOOK_TIMEOUT_SECONDS must be positive")
+	check(c.WebhookMaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS must be positive")
+	check(c.WebhookRetryBase > 0, "WEBHOOK_RETRY_BASE_SECONDS must be positive")
+	check(c.WebhookConcurrency > 0, "WEBHOOK_CONCURRENCY must be positive")
+	check(c.TeachersObject != "", "TEACHERS_OBJECT is required")
+	check(c.EventLogSize > 0, "EVENT_LOG_SIZE must be positive")
+	check(c.EventHeartbeat > 0, "EVENT_HEARTBEAT_SECONDS must be positive")
+	check(c.WebSocketMaxSubscriptions > 0, "WEBSOCKET_MAX_SUBSCRIPTIONS must be positive")
+
+	for name, value := range map[string]int{
+		"RATE_LIMIT_READ_PER_MINUTE":    c.RateLimitRead,
+		"RATE_LIMIT_READ_BURST":         c.RateLimitReadBurst,
+		"RATE_LIMIT_PRESIGN_PER_MINUTE": c.RateLimitPresign,
+		"RATE_LIMIT_PRESIGN_BURST":      c.RateLimitPresignBurst,
+		"RATE_LIMIT_ADMIN_PER_MINUTE":   c.RateLimitAdmin,
+		"RATE_LIMIT_ADMIN_BURST":        c.RateLimitAdminBurst,
+
+		"RATE_LIMIT_AUTH_FAILURES_PER_MINUTE": c.RateLimitAuthFailures,
+		"RATE_LIMIT_AUTH_FAILURES_BURST":      c.RateLimitAuthFailuresBurst,
+	} {
+		check(value >= 0, "%s must not be negative", name)
+	}
+
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			// С credentials любой сайт смог бы делать запросы от имени пользователя
+			check(!c.CORSAllowCredentials, "CORS_ALLOWED_ORIGINS: \"*\" cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+		check(err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/"),
+			"CORS_ALLOWED_ORIGINS: %q must look like scheme://host[:port]", origin)
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		check(prefixErr == nil || addrErr == nil, "TRUSTED_PROXIES: %q is not an IP address or CIDR", proxy)
+	}
+
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: unknown exporter %q (none, stdout, otlp)", c.TracingExporter))
+	}
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	check(c.JWKSFile == "" || c.JWKSURL == "", "JWKS_FILE and JWKS_URL are mutually exclusive")
+
+	return errors.Join(errs...)
+}
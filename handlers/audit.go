@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	auditMaxLimit = 1000
+	// Журнал хранится объектами по дням, и каждый день — отдельный запрос к MinIO
+	auditMaxPeriodDays = 31
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(audit *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: audit,
+	}
+}
+
+// GetAuditLog возвращает записи журнала аудита.
+// Фильтры: from, to (RFC 3339 или YYYY-MM-DD, не больше 31 дня), actor, action, university, course, type, result, limit
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+		University:   c.Query("university"),
+		Course:       c.Query("course"),
+		ScheduleType: c.Query("type"),
+		Result:       c.Query("result"),
+		Limit:        100,
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from"), false); err != nil {
+		respondError(c, models.ErrCodeInvalidParameter, "from")
+		return
+	}
+	if filter.To, err = parseAuditTime(c.Query("to"), true); err != nil {
+		respondError(c, models.ErrCodeInvalidParameter, "to")
+		return
+	}
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if !filter.From.IsZero() && (filter.From.After(to) || to.Sub(filter.From) > auditMaxPeriodDays*24*time.Hour) {
+		respondError(c, models.ErrCodeInvalidPeriod, auditMaxPeriodDays)
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 || filter.Limit > auditMaxLimit {
+			respondError(c, models.ErrCodeInvalidLimit, auditMaxLimit)
+			return
+		}
+	}
+
+	// Администратор университета видит только записи о своих университетах
+	if principal := middleware.CurrentPrincipal(c); !principal.AllUniversities() {
+		if filter.University != "" && !principal.CanAccessUniversity(filter.University) {
+			respondError(c, models.ErrCodeUniversityAccessDenied, filter.University)
+			return
+		}
+		filter.Universities = principal.Universities
+	}
+
+	entries, err := h.auditService.Query(c.Request.Context(), filter)
+	if err != nil {
+		respondStorageError(c, "failed to read audit log", err)
+		return
+	}
+
+	respond(c, http.StatusOK, entries, models.TotalMeta(len(entries)), false)
+}
+
+// parseAuditTime принимает RFC 3339 или дату YYYY-MM-DD; для верхней границы дата означает конец дня
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
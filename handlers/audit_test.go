@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/internal/s3test"
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAuditLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := s3test.NewServer(t, "schedule-api")
+	minio, err := services.NewMinIOService(&config.Config{MinIOEndpoint: server.Endpoint(), TargetBucket: "schedule-api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := json.Marshal(models.AuditEntry{ID: "a", Time: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC), Actor: "admin"})
+	server.Put("schedule-api", "audit/2026/01/10/1-a.jsonl", append(entry, '\n'))
+
+	router := gin.New()
+	router.Use(middleware.Authenticate(nil, false, nil))
+	router.GET("/admin/audit", NewAuditHandler(services.NewAuditService(minio, "schedule-api", "audit")).GetAuditLog)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode models.ErrorCode
+		wantLen  int
+	}{
+		{name: "dates", query: "from=2026-01-01&to=2026-01-31", wantLen: 1},
+		{name: "rfc 3339", query: "from=2026-01-10T00:00:00Z&to=2026-01-10T23:00:00%2B03:00", wantLen: 1},
+		{name: "exactly 31 days", query: "from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z", wantLen: 1},
+		{name: "over 31 days", query: "from=2026-01-01&to=2026-02-01", wantCode: models.ErrCodeInvalidPeriod},
+		{name: "from long before now", query: "from=2026-01-01", wantCode: models.ErrCodeInvalidPeriod},
+		{name: "from after to", query: "from=2026-01-11&to=2026-01-10", wantCode: models.ErrCodeInvalidPeriod},
+		{name: "bad from", query: "from=10.01.2026", wantCode: models.ErrCodeInvalidParameter},
+		{name: "bad to", query: "to=yesterday", wantCode: models.ErrCodeInvalidParameter},
+		{name: "zero limit", query: "from=2026-01-01&to=2026-01-31&limit=0", wantCode: models.ErrCodeInvalidLimit},
+		{name: "limit above maximum", query: "from=2026-01-01&to=2026-01-31&limit=1001", wantCode: models.ErrCodeInvalidLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := len(server.Requests())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/audit?"+tt.query, nil))
+
+			if tt.wantCode != "" {
+				var body models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != tt.wantCode.Status() || body.Code != tt.wantCode {
+					t.Errorf("got %d %s, want %s", w.Code, w.Body.String(), tt.wantCode)
+				}
+				// Отклонённый запрос не обращается к хранилищу
+				if n := len(server.Requests()); n != requests {
+					t.Errorf("%d storage requests for a rejected query", n-requests)
+				}
+				return
+			}
+
+			var body models.Response[[]models.AuditEntry]
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("got %d %s", w.Code, w.Body.String())
+			}
+			if len(body.Data) != tt.wantLen || body.Meta == nil || body.Meta.Total == nil || *body.Meta.Total != tt.wantLen {
+				t.Errorf("data = %+v, meta = %+v", body.Data, body.Meta)
+			}
+		})
+	}
+}
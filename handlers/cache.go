@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	cacheService *services.CacheService
+}
+
+func NewCacheHandler(cache *services.CacheService) *CacheHandler {
+	return &CacheHandler{
+		cacheService: cache,
+	}
+}
+
+// GetStats возвращает счётчики попаданий/промахов и объём кэша
+func (h *CacheHandler) GetStats(c *gin.Context) {
+	respond(c, http.StatusOK, h.cacheService.Stats(), nil, false)
+}
+
+// GetKeys возвращает список ключей кэша со временем истечения.
+// Параметр ?family= ограничивает выдачу одним семейством ключей (например, files:)
+func (h *CacheHandler) GetKeys(c *gin.Context) {
+	family := c.Query("family")
+
+	keys := h.cacheService.Keys()
+	if family != "" {
+		filtered := keys[:0]
+		for _, key := range keys {
+			if key.Family == family || strings.TrimSuffix(key.Family, ":") == family {
+				filtered = append(filtered, key)
+			}
+		}
+		keys = filtered
+	}
+
+	respond(c, http.StatusOK, keys, models.TotalMeta(len(keys)), false)
+}
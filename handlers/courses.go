@@ -1,10 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"net/http"
 
+	"schedule-api/layout"
 	"schedule-api/models"
 	"schedule-api/services"
 
@@ -14,61 +14,58 @@ import (
 type CourseHandler struct {
 	minioService *services.MinIOService
 	cacheService *services.CacheService
+	layouts      *layout.Resolver
 }
 
-func NewCourseHandler(minio *services.MinIOService, cache *services.CacheService) *CourseHandler {
+func NewCourseHandler(minio *services.MinIOService, cache *services.CacheService, layouts *layout.Resolver) *CourseHandler {
 	return &CourseHandler{
 		minioService: minio,
 		cacheService: cache,
+		layouts:      layouts,
 	}
 }
 
-// GetCourses возвращает список курсов для университета
+// GetCourses возвращает список курсов для университета.
+// Параметры: limit, cursor, sort (name, -name), name — подстрока имени
 func (h *CourseHandler) GetCourses(c *gin.Context) {
-	log.Println("CourseHandler - GetCourses")
 	university := c.Param("university")
 	if university == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "university parameter is required",
-		})
+		respondError(c, models.ErrCodeMissingParameter, "university")
 		return
 	}
 
-	cacheKey := fmt.Sprintf("courses:%s", university)
-
-	// Проверяем кэш
-	if cached, found := h.cacheService.Get(cacheKey); found {
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cached,
-			"cached": true,
-		})
+	q, ok := parseListQuery(c, courseSortKeys)
+	if !ok {
 		return
 	}
 
-	// Получаем из MinIO
-	prefix := fmt.Sprintf("%s/", university)
-	prefixes, err := h.minioService.ListPrefixes(c.Request.Context(), prefix)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to list courses",
-			Message: err.Error(),
-		})
-		return
+	prefix := h.layouts.For(university).CoursesPrefix(university)
+	toCourses := func(prefixes []string) []models.Course {
+		courses := make([]models.Course, 0, len(prefixes))
+		for _, name := range prefixes {
+			courses = append(courses, models.Course{
+				Name:       name,
+				University: university,
+			})
+		}
+		return courses
 	}
 
-	courses := make([]models.Course, 0, len(prefixes))
-	for _, prefix := range prefixes {
-		courses = append(courses, models.Course{
-			Name:       prefix,
-			University: university,
-		})
+	source := listSource[models.Course]{
+		cache: h.cacheService,
+		key:   fmt.Sprintf("courses:%s", university),
+		name:  courseName,
+		keys:  courseSortKeys,
+		page: func(ctx context.Context, after string, limit int) ([]models.Course, bool, error) {
+			prefixes, more, err := h.minioService.ListPrefixesPage(ctx, prefix, after, limit)
+			return toCourses(prefixes), more, err
+		},
+		all: func(ctx context.Context) ([]models.Course, error) {
+			prefixes, err := h.minioService.ListPrefixes(ctx, prefix)
+			return toCourses(prefixes), err
+		},
+	}
+	if err := source.respond(c, q); err != nil {
+		respondStorageError(c, "failed to list courses", err)
 	}
-
-	// Сохраняем в кэш
-	h.cacheService.Set(cacheKey, courses, 0)
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":   courses,
-		"cached": false,
-	})
 }
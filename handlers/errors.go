@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"schedule-api/logging"
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// respondError отправляет ошибку из каталога на языке клиента с идентификатором запроса
+func respondError(c *gin.Context, code models.ErrorCode, args ...any) {
+	c.JSON(code.Status(), middleware.NewErrorResponse(c, code, args...))
+}
+
+// respondStorageError пишет ошибку хранилища в лог и отвечает её классом, не раскрывая подробностей
+func respondStorageError(c *gin.Context, msg string, err error) {
+	code := storageErrorCode(err)
+	logging.FromContext(c.Request.Context()).Error(msg, "error", err, "code", code)
+	respondError(c, code)
+}
+
+// respond отправляет данные в общем конверте models.Response
+func respond[T any](c *gin.Context, status int, data T, meta *models.Meta, cached bool) {
+	c.JSON(status, models.Response[T]{
+		Data:      data,
+		Meta:      meta,
+		Cached:    cached,
+		RequestID: logging.RequestID(c.Request.Context()),
+	})
+}
+
+// storageErrorCode сопоставляет ошибке хранилища код ответа
+func storageErrorCode(err error) models.ErrorCode {
+	switch services.ClassifyStorageError(err) {
+	case services.ErrStorageNotFound:
+		return models.ErrCodeFileNotFound
+	case services.ErrStorageTimeout:
+		return models.ErrCodeStorageTimeout
+	case services.ErrStorageUnavailable:
+		return models.ErrCodeStorageUnavailable
+	case services.ErrStorageAccessDenied:
+		return models.ErrCodeStorageAccessDenied
+	}
+	return models.ErrCodeStorageError
+}
+
+// parseErrorCode сопоставляет ошибке разбора XLSX код ответа
+func parseErrorCode(err error) models.ErrorCode {
+	switch {
+	case errors.Is(err, services.ErrInvalidXLSX):
+		return models.ErrCodeParseInvalidXLSX
+	case errors.Is(err, services.ErrNoSheets):
+		return models.ErrCodeParseNoSheets
+	case errors.Is(err, services.ErrTooFewRows):
+		return models.ErrCodeParseTooFewRows
+	case errors.Is(err, services.ErrNoGroups):
+		return models.ErrCodeParseNoGroups
+	case errors.Is(err, services.ErrUnknownScheduleType):
+		return models.ErrCodeParseUnknownType
+	}
+	return models.ErrCodeParseFailed
+}
+
+// bindingErrorDetail описывает ошибку разбора тела запроса без внутренних имён типов Go:
+// для ошибок валидации — поля и нарушенные правила, для синтаксиса JSON — позицию
+func bindingErrorDetail(err error) string {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]string, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			// Namespace начинается с имени структуры запроса: "ProcessFilesRequest.Files[0].FileName"
+			_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+			fields = append(fields, field+" ("+fieldErr.Tag()+")")
+		}
+		return strings.Join(fields, ", ")
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return "malformed JSON at offset " + strconv.FormatInt(syntaxErr.Offset, 10)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Field + " (type)"
+	}
+	return "malformed JSON"
+}
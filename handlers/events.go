@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseRetry — пауза перед переподключением, которую браузер использует после обрыва потока
+const sseRetry = 5 * time.Second
+
+type EventHandler struct {
+	bus       *services.EventBus
+	heartbeat time.Duration
+}
+
+func NewEventHandler(bus *services.EventBus, heartbeat time.Duration) *EventHandler {
+	return &EventHandler{
+		bus:       bus,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream отдаёт поток Server-Sent Events об обновлениях расписаний.
+// Фильтры: university, course, type, group, event (типы событий через запятую).
+// При переподключении пропущенные события досылаются по заголовку Last-Event-ID;
+// если журнал их уже не содержит, первым приходит событие reset
+func (h *EventHandler) Stream(c *gin.Context) {
+	filter := models.EventFilter{
+		University:   c.Query("university"),
+		Course:       c.Query("course"),
+		ScheduleType: c.Query("type"),
+		Group:        c.Query("group"),
+		Types:        splitQueryList(c.Query("event")),
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		// EventSource не умеет задавать заголовки при первом подключении
+		lastEventID = c.Query("lastEventId")
+	}
+
+	sub, missed, resumed := h.bus.Subscribe(filter, lastEventID)
+	defer h.bus.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds())
+	if !resumed {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		writeSSEEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Шина остановлена или клиент не успевал читать — он переподключится с Last-Event-ID
+				return
+			}
+			writeSSEEvent(c.Writer, event)
+		case <-heartbeat.C:
+			// Комментарий держит соединение открытым через прокси
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeSSEEvent(w io.Writer, event models.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+// splitQueryList разбирает параметр-список через запятую, пропуская пустые значения
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// examScheduleCacheKey — ключ разобранного экзаменационного расписания; сбрасывается при обработке и восстановлении файла
+func examScheduleCacheKey(university, course, scheduleType, fileName string) string {
+	return fmt.Sprintf("exams:%s:%s:%s:%s", university, course, scheduleType, fileName)
+}
+
+// GetGroupExamSession возвращает экзаменационную сессию группы в хронологическом порядке
+func (h *ScheduleHandler) GetGroupExamSession(c *gin.Context) {
+	university := c.Param("university")
+	course := c.Param("course")
+	scheduleType := c.Param("type")
+	fileName := c.Param("filename")
+	group := c.Param("group")
+
+	cacheKey := examScheduleCacheKey(university, course, scheduleType, fileName)
+	schedule, cached := h.cachedExamSchedule(cacheKey)
+	if !cached {
+		objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
+		data, _, err := h.minioService.DownloadVersion(c.Request.Context(), objectPath, "")
+		if err != nil {
+			respondStorageError(c, "failed to download exam schedule", err)
+			return
+		}
+		if data == nil {
+			respondError(c, models.ErrCodeFileNotFound)
+			return
+		}
+
+		schedule, err = services.DecodeExamSchedule(data)
+		if errors.Is(err, services.ErrNotExamSchedule) {
+			respondError(c, models.ErrCodeExamsUnsupported)
+			return
+		}
+		if err != nil {
+			// Файл в хранилище повреждён: клиент тут ничего не исправит
+			logging.FromContext(c.Request.Context()).Error("failed to decode exam schedule", "target", objectPath, "error", err)
+			respondError(c, models.ErrCodeInternal)
+			return
+		}
+		h.cacheService.Set(cacheKey, schedule, 0)
+	}
+
+	session := services.GroupExamSession(schedule, group)
+	if session == nil {
+		respondError(c, models.ErrCodeGroupNotFound, group)
+		return
+	}
+
+	respond(c, http.StatusOK, session, models.TotalMeta(len(session.Exams)), cached)
+}
+
+func (h *ScheduleHandler) cachedExamSchedule(key string) (*models.ExamSchedule, bool) {
+	value, found := h.cacheService.Get(key)
+	if !found {
+		return nil, false
+	}
+	schedule, ok := value.(*models.ExamSchedule)
+	return schedule, ok
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"schedule-api/models"
+)
+
+func TestGetGroupExamSession(t *testing.T) {
+	env := newScheduleTestEnv(t)
+	env.server.Put("schedules", "kgu/1/exams/session.json", []byte(`{"type":"exams","groups":["23101"],"exams":[
+		{"date":"2026-01-15","time":"9.00","group":"23101","subject":"Физика"},
+		{"date":"2026-01-12","time":"9.00","group":"23101","subject":"Химия"}]}`))
+	env.server.Put("schedules", "kgu/1/regular/a.json", []byte(`{"type":"regular","groups":[{"groupNumber":"23101","days":[]}]}`))
+	env.server.Put("schedules", "kgu/1/exams/broken.json", []byte(`{"type":"exams","exams":[`))
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantErr  models.ErrorCode
+	}{
+		{name: "exam session", path: "/universities/kgu/courses/1/types/exams/files/session.json/groups/23101/exams", wantCode: http.StatusOK},
+		{name: "unknown group", path: "/universities/kgu/courses/1/types/exams/files/session.json/groups/23109/exams", wantCode: http.StatusNotFound, wantErr: models.ErrCodeGroupNotFound},
+		{name: "regular schedule", path: "/universities/kgu/courses/1/types/regular/files/a.json/groups/23101/exams", wantCode: http.StatusUnprocessableEntity, wantErr: models.ErrCodeExamsUnsupported},
+		{name: "corrupted exam schedule", path: "/universities/kgu/courses/1/types/exams/files/broken.json/groups/23101/exams", wantCode: http.StatusInternalServerError, wantErr: models.ErrCodeInternal},
+		{name: "missing file", path: "/universities/kgu/courses/1/types/exams/files/none.json/groups/23101/exams", wantCode: http.StatusNotFound, wantErr: models.ErrCodeFileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := env.do(http.MethodGet, tt.path)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantErr != "" {
+				var body models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != tt.wantErr {
+					t.Errorf("body = %s, want %s", w.Body.String(), tt.wantErr)
+				}
+				return
+			}
+
+			var body models.Response[models.ExamSession]
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Data.Exams) != 2 || body.Data.Exams[0].Subject != "Химия" || body.Data.StartDate != "2026-01-12" {
+				t.Errorf("session = %+v", body.Data)
+			}
+		})
+	}
+
+	// Разобранное расписание кэшируется: повторный запрос не читает хранилище
+	requests := len(env.server.Requests())
+	w := env.do(http.MethodGet, "/universities/kgu/courses/1/types/exams/files/session.json/groups/23101/exams")
+	var body models.Response[models.ExamSession]
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || !body.Cached || len(env.server.Requests()) != requests {
+		t.Errorf("repeated request: cached = %v, storage requests %d -> %d", body.Cached, requests, len(env.server.Requests()))
+	}
+}
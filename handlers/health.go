@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 3 * time.Second
+
+type HealthHandler struct {
+	minioService *services.MinIOService
+	buckets      map[string]string // Имя проверки -> бакет
+}
+
+func NewHealthHandler(minio *services.MinIOService, minioBucket, sourceBucket, targetBucket string) *HealthHandler {
+	return &HealthHandler{
+		minioService: minio,
+		buckets: map[string]string{
+			"minioBucket":  minioBucket,
+			"sourceBucket": sourceBucket,
+			"targetBucket": targetBucket,
+		},
+	}
+}
+
+// Liveness сообщает, что процесс жив; зависимости не проверяются
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	respond(c, http.StatusOK, models.Liveness{Status: "ok", Time: time.Now()}, nil, false)
+}
+
+// Readiness проверяет доступность MinIO и существование всех настроенных бакетов.
+// При недоступности любой зависимости возвращает 503, чтобы балансировщик снял трафик
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	// Один бакет может быть указан в нескольких настройках — проверяем его один раз
+	unique := make(map[string]struct{})
+	for _, bucket := range h.buckets {
+		unique[bucket] = struct{}{}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]models.DependencyStatus, len(unique))
+	reachable := false
+	for bucket := range unique {
+		wg.Add(1)
+		go func(bucket string) {
+			defer wg.Done()
+			status, ok := h.checkBucket(ctx, bucket)
+			mu.Lock()
+			results[bucket] = status
+			reachable = reachable || ok
+			mu.Unlock()
+		}(bucket)
+	}
+	wg.Wait()
+
+	response := models.ReadinessResponse{
+		Status: "ready",
+		Time:   time.Now(),
+		Checks: make(map[string]models.DependencyStatus, len(h.buckets)+1),
+	}
+
+	// Хранилище доступно, если хотя бы один запрос к нему завершился без ошибки
+	storage := models.DependencyStatus{
+		Status: models.HealthStatusUp,
+		Target: h.minioService.Endpoint(),
+	}
+	for _, result := range results {
+		if !reachable {
+			storage.Status = models.HealthStatusDown
+			storage.Error = result.Error
+		}
+		if result.LatencyMs > storage.LatencyMs {
+			storage.LatencyMs = result.LatencyMs
+		}
+	}
+	response.Checks["minio"] = storage
+
+	for name, bucket := range h.buckets {
+		response.Checks[name] = results[bucket]
+	}
+
+	statusCode := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status != models.HealthStatusUp {
+			response.Status = "not_ready"
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	respond(c, statusCode, response, nil, false)
+}
+
+// checkBucket проверяет бакет; второй результат сообщает, ответило ли хранилище
+func (h *HealthHandler) checkBucket(ctx context.Context, bucket string) (models.DependencyStatus, bool) {
+	start := time.Now()
+	exists, err := h.minioService.BucketExists(ctx, bucket)
+
+	status := models.DependencyStatus{
+		Status:    models.HealthStatusUp,
+		Target:    bucket,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	switch {
+	case err != nil:
+		// Ответ MinIO может раскрыть адреса и детали инфраструктуры: он остаётся в логах,
+		// в ответ попадает только класс ошибки
+		logging.FromContext(ctx).Warn("readiness check failed", "bucket", bucket, "error", err)
+		status.Status = models.HealthStatusDown
+		status.Error = "storage request failed"
+		if class := services.ClassifyStorageError(err); class != nil {
+			status.Error = class.Error()
+		}
+	case !exists:
+		status.Status = models.HealthStatusDown
+		status.Error = "bucket does not exist"
+	}
+	return status, err == nil
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"schedule-api/config"
+	"schedule-api/internal/s3test"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func readiness(t *testing.T, endpoint, minioBucket, sourceBucket, targetBucket string) (int, models.ReadinessResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	minio, err := services.NewMinIOService(&config.Config{MinIOEndpoint: endpoint, TargetBucket: targetBucket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET("/readyz", NewHealthHandler(minio, minioBucket, sourceBucket, targetBucket).Readiness)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body models.Response[models.ReadinessResponse]
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%v: %s", err, w.Body.String())
+	}
+	return w.Code, body.Data
+}
+
+func TestReadiness(t *testing.T) {
+	server := s3test.NewServer(t, "schedule-api", "uploads", "schedules")
+
+	code, ready := readiness(t, server.Endpoint(), "schedule-api", "uploads", "schedules")
+	if code != http.StatusOK || ready.Status != "ready" || len(ready.Checks) != 4 {
+		t.Fatalf("got %d %+v, want 200 ready with 4 checks", code, ready)
+	}
+
+	code, ready = readiness(t, server.Endpoint(), "schedule-api", "missing", "schedules")
+	if code != http.StatusServiceUnavailable || ready.Status != "not_ready" {
+		t.Fatalf("got %d %s, want 503 not_ready", code, ready.Status)
+	}
+	if check := ready.Checks["sourceBucket"]; check.Status != models.HealthStatusDown || check.Error != "bucket does not exist" {
+		t.Errorf("sourceBucket = %+v", check)
+	}
+	if check := ready.Checks["minio"]; check.Status != models.HealthStatusUp {
+		t.Errorf("minio = %+v, want up: the storage answered", check)
+	}
+}
+
+func TestReadinessHidesStorageErrors(t *testing.T) {
+	// Хранилище отвечает ошибками, текст которых не должен попасть в ответ
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusForbidden
+		if strings.HasPrefix(r.URL.Path, "/broken") {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+	}))
+	defer storage.Close()
+	endpoint := strings.TrimPrefix(storage.URL, "http://")
+
+	tests := []struct {
+		name   string
+		bucket string
+		want   string
+	}{
+		{name: "classified error", bucket: "denied", want: services.ErrStorageAccessDenied.Error()},
+		{name: "unclassified error", bucket: "broken", want: "storage request failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ready := readiness(t, endpoint, tt.bucket, tt.bucket, tt.bucket)
+			if code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want 503", code)
+			}
+			for name, check := range ready.Checks {
+				if check.Status != models.HealthStatusDown || check.Error != tt.want {
+					t.Errorf("%s = %+v, want down with error %q", name, check, tt.want)
+				}
+			}
+		})
+	}
+}
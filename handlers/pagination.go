@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// sortName — сортировка по умолчанию; в этом порядке MinIO сам отдаёт ключи
+const sortName = "name"
+
+// sortKeys задаёт поля сортировки списка. Ключ поля — строка, которая сравнивается
+// лексикографически, поэтому числа и даты в ней должны иметь фиксированную ширину
+type sortKeys[T any] map[string]func(T) string
+
+// listQuery — параметры страницы списка: limit, cursor, sort (name, -name, ...) и фильтр name
+type listQuery struct {
+	Limit  int
+	Sort   string
+	Desc   bool
+	Name   string // Подстрока имени в нижнем регистре
+	Cursor *pageCursor
+}
+
+// pageCursor — позиция последнего элемента отданной страницы. Клиенту передаётся непрозрачной строкой.
+// Сортировка и фильтр, с которыми получена страница, проверяются при следующем запросе
+type pageCursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Filter string `json:"f,omitempty"` // Фильтр name
+	Key    string `json:"k"`
+	Name   string `json:"n"`
+}
+
+// parseListQuery разбирает параметры страницы; при ошибке отвечает 400 и возвращает false
+func parseListQuery[T any](c *gin.Context, keys sortKeys[T]) (listQuery, bool) {
+	q := listQuery{
+		Limit: defaultPageLimit,
+		Sort:  sortName,
+		Name:  strings.ToLower(strings.TrimSpace(c.Query("name"))),
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			respondError(c, models.ErrCodeInvalidLimit, maxPageLimit)
+			return q, false
+		}
+		q.Limit = limit
+	}
+
+	if value := c.Query("sort"); value != "" {
+		q.Desc = strings.HasPrefix(value, "-")
+		q.Sort = strings.TrimPrefix(value, "-")
+		if _, ok := keys[q.Sort]; !ok {
+			fields := make([]string, 0, len(keys))
+			for field := range keys {
+				fields = append(fields, field)
+			}
+			slices.Sort(fields)
+			respondError(c, models.ErrCodeInvalidSort, strings.Join(fields, ", "))
+			return q, false
+		}
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil || cursor.Sort != q.Sort || cursor.Desc != q.Desc || cursor.Filter != q.Name {
+			respondError(c, models.ErrCodeInvalidCursor)
+			return q, false
+		}
+		q.Cursor = cursor
+	}
+
+	return q, true
+}
+
+// native сообщает, что страницу можно прочитать из MinIO сразу с нужной позиции,
+// без полного списка: MinIO отдаёт ключи по возрастанию имени
+func (q listQuery) native() bool {
+	return q.Sort == sortName && !q.Desc && q.Name == ""
+}
+
+// cursorAt возвращает курсор страницы, следующей за элементом с ключом key и именем name
+func (q listQuery) cursorAt(key, name string) pageCursor {
+	return pageCursor{Sort: q.Sort, Desc: q.Desc, Filter: q.Name, Key: key, Name: name}
+}
+
+// after — имя, после которого начинается страница
+func (q listQuery) after() string {
+	if q.Cursor == nil {
+		return ""
+	}
+	return q.Cursor.Name
+}
+
+// paginate фильтрует, сортирует и режет на страницы полный список. Исходный срез не меняется
+func paginate[T any](items []T, q listQuery, name func(T) string, keys sortKeys[T]) ([]T, *models.Meta) {
+	key := keys[q.Sort]
+
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if q.Name == "" || strings.Contains(strings.ToLower(name(item)), q.Name) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	compare := func(aKey, aName, bKey, bName string) int {
+		result := strings.Compare(aKey, bKey)
+		if result == 0 {
+			result = strings.Compare(aName, bName)
+		}
+		if q.Desc {
+			result = -result
+		}
+		return result
+	}
+	slices.SortFunc(filtered, func(a, b T) int {
+		return compare(key(a), name(a), key(b), name(b))
+	})
+
+	start := 0
+	if q.Cursor != nil {
+		// Первый элемент, стоящий после курсора: курсор остаётся верным, даже если его элемент удалён
+		start, _ = slices.BinarySearchFunc(filtered, q.Cursor, func(item T, cursor *pageCursor) int {
+			if compare(key(item), name(item), cursor.Key, cursor.Name) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	end := min(start+q.Limit, len(filtered))
+
+	total := len(filtered)
+	meta := &models.Meta{Total: &total, Limit: q.Limit}
+	if end < len(filtered) {
+		last := filtered[end-1]
+		meta.NextCursor = encodeCursor(q.cursorAt(key(last), name(last)))
+	}
+	return filtered[start:end], meta
+}
+
+// nativePageMeta описывает страницу, прочитанную из MinIO: общее число элементов неизвестно
+func nativePageMeta[T any](page []T, more bool, q listQuery, name func(T) string, keys sortKeys[T]) *models.Meta {
+	meta := &models.Meta{Limit: q.Limit}
+	if more && len(page) > 0 {
+		last := page[len(page)-1]
+		meta.NextCursor = encodeCursor(q.cursorAt(keys[q.Sort](last), name(last)))
+	}
+	return meta
+}
+
+// listSource описывает список, который можно отдать страницами
+type listSource[T any] struct {
+	cache *services.CacheService
+	key   string // Ключ кэша полного списка
+	name  func(T) string
+	keys  sortKeys[T]
+	page  func(ctx context.Context, after string, limit int) ([]T, bool, error)
+	all   func(ctx context.Context) ([]T, error)
+}
+
+// respond отдаёт страницу списка. Закэшированный полный список режется на страницы в памяти;
+// без него страница в порядке MinIO читается с позиции курсора, остальные запросы
+// читают и кэшируют полный список. Возвращённую ошибку хранилища обрабатывает вызывающий
+func (s listSource[T]) respond(c *gin.Context, q listQuery) error {
+	// Страницам в порядке MinIO полный список не нужен: его отсутствие не считается промахом
+	if !q.native() || s.cache.Contains(s.key) {
+		if cached, found := s.cache.Get(s.key); found {
+			items, meta := paginate(cached.([]T), q, s.name, s.keys)
+			respond(c, http.StatusOK, items, meta, true)
+			return nil
+		}
+	}
+
+	ctx := c.Request.Context()
+	if q.native() {
+		pageKey := pageCacheKey(s.key, q)
+		if cached, found := s.cache.Get(pageKey); found {
+			page := cached.(cachedPage[T])
+			respond(c, http.StatusOK, page.Items, nativePageMeta(page.Items, page.More, q, s.name, s.keys), true)
+			return nil
+		}
+
+		items, more, err := s.page(ctx, q.after(), q.Limit)
+		if err != nil {
+			return err
+		}
+		if items == nil {
+			items = make([]T, 0)
+		}
+		s.cache.Set(pageKey, cachedPage[T]{Items: items, More: more}, 0)
+		respond(c, http.StatusOK, items, nativePageMeta(items, more, q, s.name, s.keys), false)
+		return nil
+	}
+
+	items, err := s.all(ctx)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = make([]T, 0)
+	}
+	s.cache.Set(s.key, items, 0)
+	page, meta := paginate(items, q, s.name, s.keys)
+	respond(c, http.StatusOK, page, meta, false)
+	return nil
+}
+
+// pageCacheKey — ключ кэша страницы списка; CacheService.DeleteList удаляет её вместе со списком
+func pageCacheKey(listKey string, q listQuery) string {
+	return fmt.Sprintf("%s|%s|%d", listKey, q.after(), q.Limit)
+}
+
+// cachedPage — страница списка, прочитанная из MinIO
+type cachedPage[T any] struct {
+	Items []T
+	More  bool
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// Поля сортировки списков. Имена каталогов сравниваются с завершающим "/", как ключи в MinIO,
+// чтобы порядок совпадал при чтении из кэша и напрямую из MinIO
+var (
+	universitySortKeys = sortKeys[models.University]{
+		sortName: func(u models.University) string { return u.Name + "/" },
+	}
+	courseSortKeys = sortKeys[models.Course]{
+		sortName: func(c models.Course) string { return c.Name + "/" },
+	}
+	scheduleTypeSortKeys = sortKeys[models.ScheduleType]{
+		sortName: func(t models.ScheduleType) string { return t.Name + "/" },
+	}
+	fileSortKeys = sortKeys[models.ScheduleFile]{
+		sortName: func(f models.ScheduleFile) string { return f.Name },
+		"lastModified": func(f models.ScheduleFile) string {
+			return f.LastModified.UTC().Format("2006-01-02T15:04:05.000000000Z")
+		},
+		"size": func(f models.ScheduleFile) string { return fmt.Sprintf("%020d", f.Size) },
+	}
+	teacherSortKeys = sortKeys[models.Teacher]{
+		sortName:     func(t models.Teacher) string { return t.Name },
+		"department": func(t models.Teacher) string { return t.Department },
+	}
+)
+
+func universityName(u models.University) string     { return u.Name }
+func courseName(c models.Course) string             { return c.Name }
+func scheduleTypeName(t models.ScheduleType) string { return t.Name }
+func scheduleFileName(f models.ScheduleFile) string { return f.Name }
+func teacherName(t models.Teacher) string           { return t.Name }
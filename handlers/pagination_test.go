@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return c, w
+}
+
+func TestParseListQuery(t *testing.T) {
+	nameCursor := encodeCursor(pageCursor{Sort: "name", Key: "a.json", Name: "a.json"})
+	filteredCursor := encodeCursor(pageCursor{Sort: "size", Desc: true, Filter: "расп", Key: "0000000010", Name: "a.json"})
+
+	tests := []struct {
+		name     string
+		query    string
+		want     listQuery
+		wantCode models.ErrorCode // Пусто — запрос корректен
+	}{
+		{name: "defaults", query: "", want: listQuery{Limit: defaultPageLimit, Sort: sortName}},
+		{name: "descending sort", query: "?sort=-size&limit=5", want: listQuery{Limit: 5, Sort: "size", Desc: true}},
+		{name: "name filter is lowercased", query: "?name=%20Расп%20", want: listQuery{Limit: defaultPageLimit, Sort: sortName, Name: "расп"}},
+		{name: "cursor", query: "?cursor=" + nameCursor, want: listQuery{Limit: defaultPageLimit, Sort: sortName, Cursor: &pageCursor{Sort: "name", Key: "a.json", Name: "a.json"}}},
+		{name: "zero limit", query: "?limit=0", wantCode: models.ErrCodeInvalidLimit},
+		{name: "limit above maximum", query: "?limit=1001", wantCode: models.ErrCodeInvalidLimit},
+		{name: "limit is not a number", query: "?limit=ten", wantCode: models.ErrCodeInvalidLimit},
+		{name: "unknown sort field", query: "?sort=owner", wantCode: models.ErrCodeInvalidSort},
+		{name: "malformed cursor", query: "?cursor=not-a-cursor", wantCode: models.ErrCodeInvalidCursor},
+		{name: "cursor from another sort", query: "?sort=size&cursor=" + nameCursor, wantCode: models.ErrCodeInvalidCursor},
+		{name: "cursor from another direction", query: "?sort=-name&cursor=" + nameCursor, wantCode: models.ErrCodeInvalidCursor},
+		{name: "cursor from another filter", query: "?name=b&cursor=" + nameCursor, wantCode: models.ErrCodeInvalidCursor},
+		{name: "cursor without the filter it was issued for", query: "?cursor=" + filteredCursor, wantCode: models.ErrCodeInvalidCursor},
+		{name: "cursor with matching sort and filter", query: "?sort=-size&name=Расп&cursor=" + filteredCursor, want: listQuery{Limit: defaultPageLimit, Sort: "size", Desc: true, Name: "расп", Cursor: &pageCursor{Sort: "size", Desc: true, Filter: "расп", Key: "0000000010", Name: "a.json"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newTestContext("/files" + tt.query)
+			got, ok := parseListQuery(c, fileSortKeys)
+
+			if tt.wantCode != "" {
+				if ok {
+					t.Fatalf("got ok for %q, want %s", tt.query, tt.wantCode)
+				}
+				var body models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("invalid error body %q: %v", w.Body.String(), err)
+				}
+				if w.Code != http.StatusBadRequest || body.Code != tt.wantCode {
+					t.Errorf("got %d %s, want 400 %s", w.Code, body.Code, tt.wantCode)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("got error response %s", w.Body.String())
+			}
+			if got.Limit != tt.want.Limit || got.Sort != tt.want.Sort || got.Desc != tt.want.Desc || got.Name != tt.want.Name {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if (got.Cursor == nil) != (tt.want.Cursor == nil) || (got.Cursor != nil && *got.Cursor != *tt.want.Cursor) {
+				t.Errorf("cursor = %+v, want %+v", got.Cursor, tt.want.Cursor)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	base := time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)
+	files := []models.ScheduleFile{
+		{Name: "b.json", Size: 300, LastModified: base.Add(2 * time.Hour)},
+		{Name: "a.json", Size: 20, LastModified: base.Add(3 * time.Hour)},
+		{Name: "d.json", Size: 100, LastModified: base},
+		{Name: "c.json", Size: 100, LastModified: base.Add(time.Hour)},
+		{Name: "Exams.json", Size: 5, LastModified: base.Add(4 * time.Hour)},
+	}
+
+	tests := []struct {
+		name  string
+		query listQuery
+		want  []string
+	}{
+		{name: "by name", query: listQuery{Limit: 2, Sort: sortName}, want: []string{"Exams.json", "a.json", "b.json", "c.json", "d.json"}},
+		{name: "by name descending", query: listQuery{Limit: 2, Sort: sortName, Desc: true}, want: []string{"d.json", "c.json", "b.json", "a.json", "Exams.json"}},
+		{name: "by size with ties broken by name", query: listQuery{Limit: 2, Sort: "size"}, want: []string{"Exams.json", "a.json", "c.json", "d.json", "b.json"}},
+		{name: "newest first", query: listQuery{Limit: 3, Sort: "lastModified", Desc: true}, want: []string{"Exams.json", "a.json", "b.json", "c.json", "d.json"}},
+		{name: "name filter", query: listQuery{Limit: 1, Sort: sortName, Name: "exams"}, want: []string{"Exams.json"}},
+		{name: "one page", query: listQuery{Limit: 10, Sort: sortName}, want: []string{"Exams.json", "a.json", "b.json", "c.json", "d.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0, len(files))
+			q := tt.query
+			for pages := 0; ; pages++ {
+				if pages > len(files) {
+					t.Fatal("pagination does not terminate")
+				}
+				page, meta := paginate(files, q, scheduleFileName, fileSortKeys)
+				if meta.Total == nil || *meta.Total != len(tt.want) {
+					t.Fatalf("total = %v, want %d", meta.Total, len(tt.want))
+				}
+				if len(page) > q.Limit {
+					t.Fatalf("page has %d items, limit %d", len(page), q.Limit)
+				}
+				for _, f := range page {
+					got = append(got, f.Name)
+				}
+				if meta.NextCursor == "" {
+					break
+				}
+				cursor, err := decodeCursor(meta.NextCursor)
+				if err != nil {
+					t.Fatalf("invalid next cursor: %v", err)
+				}
+				q.Cursor = cursor
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if files[0].Name != "b.json" {
+		t.Error("paginate reordered the source slice")
+	}
+}
+
+func TestPaginateCursorSurvivesDeletion(t *testing.T) {
+	files := []models.ScheduleFile{{Name: "a.json"}, {Name: "b.json"}, {Name: "c.json"}, {Name: "d.json"}}
+	q := listQuery{Limit: 2, Sort: sortName}
+
+	_, meta := paginate(files, q, scheduleFileName, fileSortKeys)
+	cursor, err := decodeCursor(meta.NextCursor)
+	if err != nil {
+		t.Fatalf("invalid next cursor: %v", err)
+	}
+
+	// Последний элемент первой страницы удалён до запроса второй
+	q.Cursor = cursor
+	page, _ := paginate(slices.Delete(slices.Clone(files), 1, 2), q, scheduleFileName, fileSortKeys)
+
+	got := make([]string, 0, len(page))
+	for _, f := range page {
+		got = append(got, f.Name)
+	}
+	if want := []string{"c.json", "d.json"}; !slices.Equal(got, want) {
+		t.Errorf("second page = %v, want %v", got, want)
+	}
+}
+
+func TestListSourceCacheStats(t *testing.T) {
+	cache := services.NewCacheService(time.Hour, time.Hour)
+	files := []models.ScheduleFile{{Name: "a.json"}, {Name: "b.json"}, {Name: "c.json"}}
+	reads := 0
+	source := listSource[models.ScheduleFile]{
+		cache: cache,
+		key:   "files:kgu:1:regular",
+		name:  scheduleFileName,
+		keys:  fileSortKeys,
+		page: func(_ context.Context, _ string, limit int) ([]models.ScheduleFile, bool, error) {
+			reads++
+			return files[:limit], limit < len(files), nil
+		},
+		all: func(context.Context) ([]models.ScheduleFile, error) {
+			reads++
+			return files, nil
+		},
+	}
+
+	get := func(target string) {
+		t.Helper()
+		c, w := newTestContext(target)
+		q, ok := parseListQuery(c, fileSortKeys)
+		if !ok {
+			t.Fatalf("%s: %s", target, w.Body.String())
+		}
+		if err := source.respond(c, q); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("%s: %v %d", target, err, w.Code)
+		}
+	}
+
+	// Страница в порядке MinIO: промах только по ключу страницы, полный список не запрашивается
+	get("/files?limit=2")
+	get("/files?limit=2")
+	family := cache.Stats().Families["files:"]
+	if family.Misses != 1 || family.Hits != 1 || reads != 1 {
+		t.Errorf("native pages: %d misses, %d hits, %d reads; want 1, 1, 1", family.Misses, family.Hits, reads)
+	}
+
+	// Сортировка по убыванию читает и кэширует полный список, после чего и страницы MinIO берутся из него
+	get("/files?sort=-name")
+	get("/files?limit=2")
+	family = cache.Stats().Families["files:"]
+	if family.Misses != 2 || family.Hits != 2 || reads != 2 {
+		t.Errorf("full list: %d misses, %d hits, %d reads; want 2, 2, 2", family.Misses, family.Hits, reads)
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	"schedule-api/layout"
+	"schedule-api/logging"
 	"schedule-api/models"
 	"schedule-api/services"
 
@@ -13,109 +16,104 @@ import (
 type ScheduleHandler struct {
 	minioService *services.MinIOService
 	cacheService *services.CacheService
+	auditService *services.AuditService
+	diffService  *services.DiffService
+	events       *services.EventBus
+	layouts      *layout.Resolver
 }
 
-func NewScheduleHandler(minio *services.MinIOService, cache *services.CacheService) *ScheduleHandler {
+func NewScheduleHandler(minio *services.MinIOService, cache *services.CacheService, audit *services.AuditService, diffs *services.DiffService, events *services.EventBus, layouts *layout.Resolver) *ScheduleHandler {
 	return &ScheduleHandler{
 		minioService: minio,
 		cacheService: cache,
+		auditService: audit,
+		diffService:  diffs,
+		events:       events,
+		layouts:      layouts,
 	}
 }
 
-// GetScheduleTypes возвращает список типов расписаний
+// GetScheduleTypes возвращает список типов расписаний.
+// Параметры: limit, cursor, sort (name, -name), name — подстрока имени
 func (h *ScheduleHandler) GetScheduleTypes(c *gin.Context) {
 	university := c.Param("university")
 	course := c.Param("course")
 
 	if university == "" || course == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "university and course parameters are required",
-		})
+		respondError(c, models.ErrCodeMissingParameter, "university, course")
 		return
 	}
 
-	cacheKey := fmt.Sprintf("types:%s:%s", university, course)
-
-	// Проверяем кэш
-	if cached, found := h.cacheService.Get(cacheKey); found {
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cached,
-			"cached": true,
-		})
+	q, ok := parseListQuery(c, scheduleTypeSortKeys)
+	if !ok {
 		return
 	}
 
-	// Получаем из MinIO
-	prefix := fmt.Sprintf("%s/%s/", university, course)
-	prefixes, err := h.minioService.ListPrefixes(c.Request.Context(), prefix)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to list schedule types",
-			Message: err.Error(),
-		})
-		return
+	prefix := h.layouts.For(university).TypesPrefix(university, course)
+	toTypes := func(prefixes []string) []models.ScheduleType {
+		types := make([]models.ScheduleType, 0, len(prefixes))
+		for _, name := range prefixes {
+			types = append(types, models.ScheduleType{
+				Name:       name,
+				University: university,
+				Course:     course,
+			})
+		}
+		return types
 	}
 
-	types := make([]models.ScheduleType, 0, len(prefixes))
-	for _, prefix := range prefixes {
-		types = append(types, models.ScheduleType{
-			Name:       prefix,
-			University: university,
-			Course:     course,
-		})
+	source := listSource[models.ScheduleType]{
+		cache: h.cacheService,
+		key:   fmt.Sprintf("types:%s:%s", university, course),
+		name:  scheduleTypeName,
+		keys:  scheduleTypeSortKeys,
+		page: func(ctx context.Context, after string, limit int) ([]models.ScheduleType, bool, error) {
+			prefixes, more, err := h.minioService.ListPrefixesPage(ctx, prefix, after, limit)
+			return toTypes(prefixes), more, err
+		},
+		all: func(ctx context.Context) ([]models.ScheduleType, error) {
+			prefixes, err := h.minioService.ListPrefixes(ctx, prefix)
+			return toTypes(prefixes), err
+		},
+	}
+	if err := source.respond(c, q); err != nil {
+		respondStorageError(c, "failed to list schedule types", err)
 	}
-
-	// Сохраняем в кэш
-	h.cacheService.Set(cacheKey, types, 0)
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":   types,
-		"cached": false,
-	})
 }
 
-// GetScheduleFiles возвращает список файлов расписаний
+// GetScheduleFiles возвращает список текущих файлов расписаний.
+// Параметры: limit, cursor, sort (name, lastModified, size; с "-" — по убыванию), name — подстрока имени
 func (h *ScheduleHandler) GetScheduleFiles(c *gin.Context) {
 	university := c.Param("university")
 	course := c.Param("course")
 	scheduleType := c.Param("type")
 
 	if university == "" || course == "" || scheduleType == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "all path parameters are required",
-		})
+		respondError(c, models.ErrCodeMissingParameter, "university, course, type")
 		return
 	}
 
-	cacheKey := fmt.Sprintf("files:%s:%s:%s", university, course, scheduleType)
-	// Проверяем кэш
-	if cached, found := h.cacheService.Get(cacheKey); found {
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cached,
-			"cached": true,
-		})
+	q, ok := parseListQuery(c, fileSortKeys)
+	if !ok {
 		return
 	}
 
-	// Получаем из MinIO
-	prefix := fmt.Sprintf("%s/%s/%s/", university, course, scheduleType)
-
-	files, err := h.minioService.ListFiles(c.Request.Context(), prefix)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to list schedule files",
-			Message: err.Error(),
-		})
-		return
+	prefix := h.layouts.For(university).FilesPrefix(university, course, scheduleType)
+	source := listSource[models.ScheduleFile]{
+		cache: h.cacheService,
+		key:   fmt.Sprintf("files:%s:%s:%s", university, course, scheduleType),
+		name:  scheduleFileName,
+		keys:  fileSortKeys,
+		page: func(ctx context.Context, after string, limit int) ([]models.ScheduleFile, bool, error) {
+			return h.minioService.ListFilesPage(ctx, prefix, after, limit)
+		},
+		all: func(ctx context.Context) ([]models.ScheduleFile, error) {
+			return h.minioService.ListFiles(ctx, prefix)
+		},
+	}
+	if err := source.respond(c, q); err != nil {
+		respondStorageError(c, "failed to list schedule files", err)
 	}
-
-	// Сохраняем в кэш
-	h.cacheService.Set(cacheKey, files, 0)
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":   files,
-		"cached": false,
-	})
 }
 
 // GetPresignedDownloadURL возвращает presigned URL для скачивания
@@ -126,48 +124,44 @@ func (h *ScheduleHandler) GetPresignedDownloadURL(c *gin.Context) {
 	fileName := c.Param("filename")
 
 	if university == "" || course == "" || scheduleType == "" || fileName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "all parameters are required",
-		})
+		respondError(c, models.ErrCodeMissingParameter, "university, course, type, filename")
 		return
 	}
 
-	objectPath := fmt.Sprintf("%s/%s/%s/%s", university, course, scheduleType, fileName)
+	objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
 
 	// Проверяем существование файла
 	exists, err := h.minioService.ObjectExists(c.Request.Context(), objectPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to check file existence",
-			Message: err.Error(),
-		})
+		respondStorageError(c, "failed to check file existence", err)
 		return
 	}
 
 	if !exists {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error: "file not found",
-		})
+		respondError(c, models.ErrCodeFileNotFound)
 		return
 	}
 
 	// Генерируем presigned URL
 	urlResponse, err := h.minioService.GetPresignedURL(c.Request.Context(), objectPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to generate download url",
-			Message: err.Error(),
-		})
+		respondStorageError(c, "failed to generate download url", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, urlResponse)
+	respond(c, http.StatusOK, urlResponse, nil, false)
 }
 
 // InvalidateCache удаляет кэш (для будущих webhook'ов)
 func (h *ScheduleHandler) InvalidateCache(c *gin.Context) {
 	h.cacheService.Flush()
-	c.JSON(http.StatusOK, gin.H{
-		"message": "cache invalidated successfully",
-	})
+	h.events.Publish(models.Event{Type: models.EventCacheInvalidated})
+
+	entry := newAuditEntry(c, models.AuditActionCacheInvalidate)
+	entry.Result = models.AuditResultSuccess
+	if err := h.auditService.Record(c.Request.Context(), entry); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to write audit log", "error", err)
+	}
+
+	respond(c, http.StatusOK, models.Message{Message: "cache invalidated successfully"}, nil, false)
 }
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeacherHandler struct {
+	teachers     *services.TeacherDirectory
+	auditService *services.AuditService
+}
+
+func NewTeacherHandler(teachers *services.TeacherDirectory, audit *services.AuditService) *TeacherHandler {
+	return &TeacherHandler{
+		teachers:     teachers,
+		auditService: audit,
+	}
+}
+
+// GetTeachers возвращает справочник преподавателей.
+// Параметры: limit, cursor, sort (name, department; с "-" — по убыванию), name — подстрока имени
+func (h *TeacherHandler) GetTeachers(c *gin.Context) {
+	q, ok := parseListQuery(c, teacherSortKeys)
+	if !ok {
+		return
+	}
+
+	teachers, err := h.teachers.Teachers(c.Request.Context())
+	if err != nil {
+		respondStorageError(c, "failed to load teacher directory", err)
+		return
+	}
+
+	page, meta := paginate(teachers, q, teacherName, teacherSortKeys)
+	respond(c, http.StatusOK, page, meta, false)
+}
+
+// GetTeacher возвращает преподавателя по ID или по имени в любом написании ("Гареева Г. А.")
+func (h *TeacherHandler) GetTeacher(c *gin.Context) {
+	id := c.Param("id")
+
+	teacher, err := h.teachers.Teacher(c.Request.Context(), id)
+	if err == nil && teacher == nil {
+		teacher, err = h.teachers.Teacher(c.Request.Context(), models.TeacherID(id))
+	}
+	if err != nil {
+		respondStorageError(c, "failed to load teacher directory", err)
+		return
+	}
+	if teacher == nil {
+		respondError(c, models.ErrCodeTeacherNotFound, id)
+		return
+	}
+
+	respond(c, http.StatusOK, teacher, nil, false)
+}
+
+// SetMapping заменяет сопоставление кратких имён с ФИО и кафедрами
+func (h *TeacherHandler) SetMapping(c *gin.Context) {
+	var req models.TeacherMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, models.ErrCodeInvalidRequestBody, bindingErrorDetail(err))
+		return
+	}
+
+	entry := newAuditEntry(c, models.AuditActionTeacherMapping)
+	entry.Result = models.AuditResultFailure
+	defer func() {
+		if err := h.auditService.Record(c.Request.Context(), entry); err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to write audit log", "error", err)
+		}
+	}()
+
+	teachers, unknown, err := h.teachers.SetMapping(c.Request.Context(), req.Teachers)
+	if err != nil {
+		entry.Error = err.Error()
+		respondStorageError(c, "failed to save teacher mapping", err)
+		return
+	}
+	if len(unknown) > 0 {
+		entry.Error = "unknown teacher ids: " + strings.Join(unknown, ", ")
+		respondError(c, models.ErrCodeTeacherNotFound, strings.Join(unknown, ", "))
+		return
+	}
+	entry.Result = models.AuditResultSuccess
+
+	respond(c, http.StatusOK, teachers, models.TotalMeta(len(teachers)), false)
+}
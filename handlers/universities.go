@@ -1,9 +1,11 @@
 package handlers
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"slices"
+	"strings"
 
+	"schedule-api/layout"
 	"schedule-api/models"
 	"schedule-api/services"
 
@@ -13,49 +15,91 @@ import (
 type UniversityHandler struct {
 	minioService *services.MinIOService
 	cacheService *services.CacheService
+	layouts      *layout.Resolver
+	servicePaths []string // Данные сервиса в бакете расписаний, см. config.ServicePaths
 }
 
-func NewUniversityHandler(minio *services.MinIOService, cache *services.CacheService) *UniversityHandler {
+func NewUniversityHandler(minio *services.MinIOService, cache *services.CacheService, layouts *layout.Resolver, servicePaths []string) *UniversityHandler {
 	return &UniversityHandler{
 		minioService: minio,
 		cacheService: cache,
+		layouts:      layouts,
+		servicePaths: servicePaths,
 	}
 }
 
-// GetUniversities возвращает список университетов
+// GetUniversities возвращает список университетов.
+// Параметры: limit, cursor, sort (name, -name), name — подстрока имени
 func (h *UniversityHandler) GetUniversities(c *gin.Context) {
-	log.Println("UniversityHandler - GetUniversities")
-	cacheKey := "universities"
-
-	// Проверяем кэш
-	if cached, found := h.cacheService.Get(cacheKey); found {
-		c.JSON(http.StatusOK, gin.H{
-			"data":   cached,
-			"cached": true,
-		})
+	q, ok := parseListQuery(c, universitySortKeys)
+	if !ok {
 		return
 	}
 
-	// Получаем из MinIO
-	prefixes, err := h.minioService.ListPrefixes(c.Request.Context(), "")
+	source := listSource[models.University]{
+		cache: h.cacheService,
+		key:   "universities",
+		name:  universityName,
+		keys:  universitySortKeys,
+		page:  h.listPage,
+		all:   h.listAll,
+	}
+	if err := source.respond(c, q); err != nil {
+		respondStorageError(c, "failed to list universities", err)
+	}
+}
+
+func (h *UniversityHandler) listAll(ctx context.Context) ([]models.University, error) {
+	prefixes, err := h.minioService.ListPrefixes(ctx, h.layouts.Default().UniversitiesPrefix())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "failed to list universities",
-			Message: err.Error(),
-		})
-		return
+		return nil, err
 	}
 
-	universities := make([]models.University, 0, len(prefixes))
-	for _, prefix := range prefixes {
-		universities = append(universities, models.University{Name: prefix})
+	// Университеты с собственной раскладкой могут лежать вне общего префикса
+	for _, name := range h.layouts.CustomUniversities() {
+		if !slices.Contains(prefixes, name) {
+			prefixes = append(prefixes, name)
+		}
 	}
 
-	// Сохраняем в кэш
-	h.cacheService.Set(cacheKey, universities, 0)
+	return h.toUniversities(prefixes), nil
+}
+
+func (h *UniversityHandler) listPage(ctx context.Context, after string, limit int) ([]models.University, bool, error) {
+	// Папки с данными сервиса отбрасываются, поэтому читаем с запасом на них
+	prefixes, more, err := h.minioService.ListPrefixesPage(ctx, h.layouts.Default().UniversitiesPrefix(), after, limit+len(h.servicePaths))
+	if err != nil {
+		return nil, false, err
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":   universities,
-		"cached": false,
+	// Университеты с собственной раскладкой встают на свои места по имени; не попавшие
+	// на эту страницу окажутся на следующих, так как идут после её последнего элемента
+	for _, name := range h.layouts.CustomUniversities() {
+		if name+"/" > after+"/" && !slices.Contains(prefixes, name) {
+			prefixes = append(prefixes, name)
+		}
+	}
+	universities := h.toUniversities(prefixes)
+	slices.SortFunc(universities, func(a, b models.University) int {
+		return strings.Compare(a.Name+"/", b.Name+"/")
 	})
+	if len(universities) > limit {
+		universities, more = universities[:limit], true
+	}
+	return universities, more, nil
+}
+
+// toUniversities пропускает папки, в которых лежат данные самого сервиса: при раскладке
+// с {university} в первом сегменте audit/, diffs/ и подобные оказываются рядом с университетами
+func (h *UniversityHandler) toUniversities(prefixes []string) []models.University {
+	universities := make([]models.University, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if slices.ContainsFunc(h.servicePaths, func(path string) bool {
+			return h.layouts.Default().InUniversity(path, prefix)
+		}) {
+			continue
+		}
+		universities = append(universities, models.University{Name: prefix})
+	}
+	return universities
 }
@@ -2,33 +2,55 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"schedule-api/layout"
+	"schedule-api/logging"
+	"schedule-api/metrics"
+	"schedule-api/middleware"
 	"schedule-api/models"
 	"schedule-api/services"
+	"schedule-api/tracing"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type UploadFileHandler struct {
-	minioService    *services.MinIOService
-	parserService   *services.ParserService
-	cacheService    *services.CacheService
-	sourceBucket    string
-	targetBucket    string
-	filePathPattern string
+	minioService  *services.MinIOService
+	parserService *services.ParserService
+	cacheService  *services.CacheService
+	auditService  *services.AuditService
+	diffService   *services.DiffService
+	webhooks      *services.WebhookService
+	teachers      *services.TeacherDirectory
+	events        *services.EventBus
+	jobTracker    *services.JobTracker
+	sourceBucket  string
+	targetBucket  string
+	layouts       *layout.Resolver
 }
 
-func NewUploadFileHandler(minio *services.MinIOService, cache *services.CacheService, sourceBucket, targetBucket, filePathPattern string) *UploadFileHandler {
+func NewUploadFileHandler(minio *services.MinIOService, cache *services.CacheService, audit *services.AuditService, diffs *services.DiffService, webhooks *services.WebhookService, teachers *services.TeacherDirectory, events *services.EventBus, jobs *services.JobTracker, sourceBucket, targetBucket string, layouts *layout.Resolver) *UploadFileHandler {
 	return &UploadFileHandler{
-		minioService:    minio,
-		parserService:   services.NewParserService(),
-		cacheService:    cache,
-		sourceBucket:    sourceBucket,
-		targetBucket:    targetBucket,
-		filePathPattern: filePathPattern,
+		minioService:  minio,
+		parserService: services.NewParserService(),
+		cacheService:  cache,
+		auditService:  audit,
+		diffService:   diffs,
+		webhooks:      webhooks,
+		teachers:      teachers,
+		events:        events,
+		jobTracker:    jobs,
+		sourceBucket:  sourceBucket,
+		targetBucket:  targetBucket,
+		layouts:       layouts,
 	}
 }
 
@@ -43,34 +65,60 @@ type ProcessFilesRequest struct {
 	Files []FileItem `json:"files" binding:"required,min=1"`
 }
 
+// ProcessFilesResult — итог обработки всех файлов запроса
+type ProcessFilesResult struct {
+	Message      string              `json:"message"`
+	Total        int                 `json:"total"`
+	Succeeded    int                 `json:"succeeded"`
+	Failed       int                 `json:"failed"`
+	Results      []ProcessFileResult `json:"results"`
+	SourceBucket string              `json:"source_bucket"`
+	TargetBucket string              `json:"target_bucket"`
+}
+
 type ProcessFileResult struct {
-	FileName   string `json:"file_name"`
-	SourceFile string `json:"source_file"`
-	TargetFile string `json:"target_file"`
-	Success    bool   `json:"success"`
-	Error      string `json:"error,omitempty"`
+	FileName   string              `json:"file_name"`
+	SourceFile string              `json:"source_file"`
+	TargetFile string              `json:"target_file"`
+	Success    bool                `json:"success"`
+	Code       models.ErrorCode    `json:"code,omitempty"`
+	Error      string              `json:"error,omitempty"` // Сообщение на языке клиента; подробности — в журнале аудита
+	Diff       *models.DiffSummary `json:"diff,omitempty"`  // Изменения относительно предыдущей версии (только основное расписание)
 }
 
 func (h *UploadFileHandler) ProcessFile(c *gin.Context) {
-	log.Println("UploadFileHandler - ProcessFile")
-
 	var req ProcessFilesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid request body",
-			Message: err.Error(),
-		})
+		respondError(c, models.ErrCodeInvalidRequestBody, bindingErrorDetail(err))
 		return
 	}
 
 	results := make([]ProcessFileResult, 0, len(req.Files))
+	auditEntries := make([]models.AuditEntry, 0, len(req.Files))
 	successCount := 0
 	failureCount := 0
 
+	metrics.ProcessingQueueDepth.Add(float64(len(req.Files)))
+
+	// Регистрируем задание, чтобы при остановке сервиса дождаться его завершения
+	actor := newAuditEntry(c, models.AuditActionProcessFile).Actor
+	job := h.jobTracker.Start(logging.RequestID(c.Request.Context()), actor, len(req.Files))
+	defer job.Finish()
+
 	// Обрабатываем каждый файл
 	for _, fileItem := range req.Files {
-		result := h.processOneFile(c, fileItem)
+		entry := newAuditEntry(c, models.AuditActionProcessFile)
+		entry.University = fileItem.University
+		entry.Course = fileItem.Course
+		entry.ScheduleType = fileItem.ScheduleType
+		entry.File = fileItem.FileName
+
+		job.Processing(fmt.Sprintf("%s/%s/%s/%s", fileItem.University, fileItem.Course, fileItem.ScheduleType, fileItem.FileName))
+		result := h.processOneFile(c, fileItem, &entry)
+		job.FileDone()
+		metrics.ProcessingQueueDepth.Dec()
 		results = append(results, result)
+		auditEntries = append(auditEntries, entry)
 
 		if result.Success {
 			successCount++
@@ -79,6 +127,13 @@ func (h *UploadFileHandler) ProcessFile(c *gin.Context) {
 		}
 	}
 
+	// Журнал пишем даже если запрос уже отменён: файлы могли быть загружены до отмены
+	auditCtx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 10*time.Second)
+	defer cancel()
+	if err := h.auditService.Record(auditCtx, auditEntries...); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to write audit log", "error", err)
+	}
+
 	// Формируем итоговый ответ
 	statusCode := http.StatusOK
 	if failureCount > 0 && successCount == 0 {
@@ -87,90 +142,248 @@ func (h *UploadFileHandler) ProcessFile(c *gin.Context) {
 		statusCode = http.StatusMultiStatus
 	}
 
-	c.JSON(statusCode, gin.H{
-		"message":       fmt.Sprintf("processed %d files: %d succeeded, %d failed", len(req.Files), successCount, failureCount),
-		"total":         len(req.Files),
-		"succeeded":     successCount,
-		"failed":        failureCount,
-		"results":       results,
-		"source_bucket": h.sourceBucket,
-		"target_bucket": h.targetBucket,
-	})
+	respond(c, statusCode, ProcessFilesResult{
+		Message:      fmt.Sprintf("processed %d files: %d succeeded, %d failed", len(req.Files), successCount, failureCount),
+		Total:        len(req.Files),
+		Succeeded:    successCount,
+		Failed:       failureCount,
+		Results:      results,
+		SourceBucket: h.sourceBucket,
+		TargetBucket: h.targetBucket,
+	}, nil, false)
 }
 
-func (h *UploadFileHandler) processOneFile(c *gin.Context, fileItem FileItem) ProcessFileResult {
+// processOneFile обрабатывает один файл и заполняет запись аудита entry
+func (h *UploadFileHandler) processOneFile(c *gin.Context, fileItem FileItem, entry *models.AuditEntry) ProcessFileResult {
 	result := ProcessFileResult{
 		FileName: fileItem.FileName,
 		Success:  false,
 	}
+	ctx, span := tracing.Start(c.Request.Context(), "process.file", trace.WithAttributes(
+		attribute.String("schedule.university", fileItem.University),
+		attribute.String("schedule.course", fileItem.Course),
+		attribute.String("schedule.type", fileItem.ScheduleType),
+		attribute.String("schedule.file", fileItem.FileName),
+	))
+	logger := logging.FromContext(ctx).With(
+		"university", fileItem.University,
+		"course", fileItem.Course,
+		"schedule_type", fileItem.ScheduleType,
+		"file", fileItem.FileName,
+	)
+
+	// fail заполняет результат кодом и сообщением для клиента, а причину сохраняет для аудита
+	var cause error
+	fail := func(err error, code models.ErrorCode, args ...any) ProcessFileResult {
+		cause = err
+		result.Code = code
+		result.Error = code.Message(middleware.Language(c), args...)
+		return result
+	}
+
+	entry.Result = models.AuditResultFailure
+	defer func() {
+		entry.SourcePath = result.SourceFile
+		entry.TargetPath = result.TargetFile
+		if result.Success {
+			entry.Result = models.AuditResultSuccess
+			tracing.End(span, nil)
+		} else if cause != nil {
+			entry.Error = cause.Error()
+			tracing.End(span, cause)
+		}
+	}()
+
+	// Проверяем, что клиент может обрабатывать файлы этого университета
+	if !middleware.CurrentPrincipal(c).CanAccessUniversity(fileItem.University) {
+		entry.Result = models.AuditResultDenied
+		logger.Warn("access to university denied")
+		return fail(fmt.Errorf("access to university %s denied", fileItem.University), models.ErrCodeUniversityAccessDenied, fileItem.University)
+	}
 
 	// Формируем путь к XLSX файлу в бакете file-upload
-	xlsxPath := fmt.Sprintf(h.filePathPattern, fileItem.University, fileItem.Course, fileItem.ScheduleType, fileItem.FileName)
+	fileLayout := h.layouts.For(fileItem.University)
+	xlsxPath := fileLayout.ObjectPath(fileItem.University, fileItem.Course, fileItem.ScheduleType, fileItem.FileName)
 	result.SourceFile = xlsxPath
 
 	// Проверяем существование файла перед скачиванием
-	log.Printf("Проверка существования файла в %s: %s", h.sourceBucket, xlsxPath)
-	exists, err := h.minioService.ObjectExistsInBucket(c.Request.Context(), h.sourceBucket, xlsxPath)
+	logger = logger.With("source", xlsxPath)
+	sourceInfo, err := h.minioService.StatObjectInBucket(ctx, h.sourceBucket, xlsxPath)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to check file existence: %v", err)
-		log.Printf("Ошибка проверки существования %s: %v", xlsxPath, err)
-		return result
+		logger.Error("failed to check source file", "bucket", h.sourceBucket, "error", err)
+		return fail(fmt.Errorf("failed to check file existence: %w", err), storageErrorCode(err))
 	}
-	if !exists {
-		result.Error = fmt.Sprintf("file not found in bucket: %s", xlsxPath)
-		log.Printf("Файл не найден в %s: %s", h.sourceBucket, xlsxPath)
-		return result
+	if sourceInfo == nil {
+		logger.Warn("source file not found", "bucket", h.sourceBucket)
+		return fail(fmt.Errorf("file not found in bucket: %s", xlsxPath), models.ErrCodeSourceNotFound, fileItem.FileName)
 	}
+	entry.SourceETag = sourceInfo.ETag
 
 	// Скачиваем XLSX файл из source bucket
-	log.Printf("Скачивание файла из %s: %s", h.sourceBucket, xlsxPath)
-	xlsxData, err := h.minioService.DownloadFile(c.Request.Context(), h.sourceBucket, xlsxPath)
+	xlsxData, err := h.minioService.DownloadFile(ctx, h.sourceBucket, xlsxPath)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to download file: %v", err)
-		log.Printf("Ошибка скачивания %s: %v", xlsxPath, err)
-		return result
+		logger.Error("failed to download source file", "error", err)
+		return fail(fmt.Errorf("failed to download file: %w", err), storageErrorCode(err))
 	}
 
 	// Валидируем XLSX файл
 	reader := bytes.NewReader(xlsxData)
-	valid, err := h.parserService.ValidateScheduleFile(reader, fileItem.ScheduleType)
+	valid, err := h.parserService.ValidateScheduleFile(ctx, reader, fileItem.ScheduleType)
 	if err != nil || !valid {
-		result.Error = fmt.Sprintf("invalid schedule file: %v", err)
-		log.Printf("Ошибка валидации %s: %v", xlsxPath, err)
-		return result
+		logger.Warn("invalid schedule file", "error", err)
+		return fail(fmt.Errorf("invalid schedule file: %w", err), parseErrorCode(err))
 	}
 
 	// Возвращаемся в начало после валидации
 	reader.Seek(0, 0)
 
 	// Парсим XLSX в JSON
-	log.Printf("Парсинг файла: %s", fileItem.FileName)
-	jsonData, err := h.parserService.ParseXLSXToJSON(reader, fileItem.ScheduleType)
+	jsonData, err := h.parserService.ParseXLSXToJSON(ctx, reader, fileItem.ScheduleType)
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to parse file: %v", err)
-		log.Printf("Ошибка парсинга %s: %v", xlsxPath, err)
-		return result
+		logger.Warn("failed to parse file", "error", err)
+		code := parseErrorCode(err)
+		var args []any
+		if code == models.ErrCodeParseUnknownType {
+			args = append(args, fileItem.ScheduleType)
+		}
+		return fail(fmt.Errorf("failed to parse file: %w", err), code, args...)
 	}
 
 	// Формируем путь для JSON файла в целевом бакете
 	jsonFileName := strings.TrimSuffix(fileItem.FileName, ".xlsx") + ".json"
-	jsonPath := fmt.Sprintf(h.filePathPattern, fileItem.University, fileItem.Course, fileItem.ScheduleType, jsonFileName)
+	jsonPath := fileLayout.ObjectPath(fileItem.University, fileItem.Course, fileItem.ScheduleType, jsonFileName)
 	result.TargetFile = jsonPath
 
+	// Запоминаем, какую версию JSON мы заменяем
+	previous, err := h.minioService.StatObjectInBucket(ctx, h.targetBucket, jsonPath)
+	if err == nil && previous != nil {
+		entry.ReplacedETag = previous.ETag
+	}
+
+	// Сравниваем с предыдущей версией до загрузки, пока она ещё текущая
+	diff := h.diffWithPrevious(ctx, logger, jsonPath, previous, jsonData)
+
 	// Загружаем JSON в target bucket
-	log.Printf("Загрузка JSON в %s: %s", h.targetBucket, jsonPath)
-	err = h.minioService.UploadFile(c.Request.Context(), h.targetBucket, jsonPath, bytes.NewReader(jsonData), int64(len(jsonData)), "application/json")
+	version, err := h.minioService.UploadFile(ctx, h.targetBucket, jsonPath, bytes.NewReader(jsonData), int64(len(jsonData)), "application/json")
 	if err != nil {
-		result.Error = fmt.Sprintf("failed to upload json: %v", err)
-		log.Printf("Ошибка загрузки %s: %v", jsonPath, err)
-		return result
+		logger.Error("failed to upload json", "target", jsonPath, "error", err)
+		return fail(fmt.Errorf("failed to upload json: %w", err), storageErrorCode(err))
+	}
+
+	// Сохраняем разницу вместе с запуском обработки; ошибка не отменяет обработку файла
+	if diff != nil {
+		diff.University = fileItem.University
+		diff.Course = fileItem.Course
+		diff.ScheduleType = fileItem.ScheduleType
+		diff.File = jsonFileName
+		diff.To = version
+		if err := h.diffService.Store(ctx, jsonPath, diff); err != nil {
+			logger.Warn("failed to store schedule diff", "error", err)
+		}
+		result.Diff = &diff.Summary
+	}
+
+	event := models.Event{
+		Type:         models.EventScheduleProcessed,
+		University:   fileItem.University,
+		Course:       fileItem.Course,
+		ScheduleType: fileItem.ScheduleType,
+		File:         jsonFileName,
+		Version:      version,
+	}
+	if diff != nil {
+		event.Groups = diff.Summary.Groups
+		event.Teachers = diff.Teachers()
+		event.Summary = &diff.Summary
+	}
+
+	// Для замен и экзаменов изменения не вычисляются: событие касается всех групп
+	// и преподавателей файла. Преподаватели всех типов файлов пополняют справочник
+	var teachers []string
+	var replacements models.ReplacementSchedule
+	if schedule, err := services.DecodeRegularSchedule(jsonData); err == nil {
+		teachers = services.ScheduleTeachers(schedule)
+	} else if exams, err := services.DecodeExamSchedule(jsonData); err == nil {
+		teachers = services.ExamTeachers(exams)
+		event.Groups, event.Teachers = services.ExamGroups(exams), teachers
+	} else if err := json.Unmarshal(jsonData, &replacements); err == nil && replacements.Type == "replacements" {
+		teachers = services.ReplacementTeachers(&replacements)
+		event.Groups, event.Teachers, event.Dates = replacements.Groups, teachers, replacements.Dates
+	}
+
+	// Ошибка справочника не отменяет обработку файла
+	if err := h.teachers.Register(ctx, teachers); err != nil {
+		logger.Warn("failed to update teacher directory", "error", err)
 	}
 
 	// Инвалидируем кэш для этого расписания
 	cacheKey := fmt.Sprintf("files:%s:%s:%s", fileItem.University, fileItem.Course, fileItem.ScheduleType)
-	h.cacheService.Delete(cacheKey)
+	h.cacheService.DeleteList(cacheKey)
+	h.cacheService.Delete(examScheduleCacheKey(fileItem.University, fileItem.Course, fileItem.ScheduleType, jsonFileName))
+
+	// Повторная загрузка без изменений не считается обновлением — как и для webhook'ов
+	if diff == nil || !diff.Summary.Empty() {
+		h.events.Publish(event)
+	}
+
+	// Уведомляем подписчиков; доставка идёт в фоне и не задерживает ответ
+	payload := models.WebhookPayload{
+		University:   fileItem.University,
+		Course:       fileItem.Course,
+		ScheduleType: fileItem.ScheduleType,
+		File:         jsonFileName,
+		Version:      version,
+		Diff:         diff,
+	}
+	if diff == nil {
+		payload.Groups, payload.Teachers = event.Groups, event.Teachers
+	}
+	if err := h.webhooks.Notify(ctx, payload); err != nil {
+		logger.Warn("failed to notify webhook subscribers", "error", err)
+	}
 
-	log.Printf("Файл успешно обработан: %s -> %s", xlsxPath, jsonPath)
+	logger.Info("file processed", "target", jsonPath)
 	result.Success = true
 	return result
 }
+
+// diffWithPrevious сравнивает новое основное расписание с текущей версией в target bucket.
+// Возвращает nil для замен и экзаменов, а также если сравнить не удалось
+func (h *UploadFileHandler) diffWithPrevious(ctx context.Context, logger *slog.Logger, jsonPath string, previous *models.ScheduleFile, jsonData []byte) *models.ScheduleDiff {
+	after, err := services.DecodeRegularSchedule(jsonData)
+	if err != nil {
+		return nil
+	}
+
+	var before *models.RegularSchedule
+	from := ""
+	if previous != nil {
+		data, err := h.minioService.DownloadFile(ctx, h.targetBucket, jsonPath)
+		if err == nil {
+			before, err = services.DecodeRegularSchedule(data)
+		}
+		if err != nil {
+			logger.Warn("failed to read previous schedule version for diff", "error", err)
+			return nil
+		}
+		from = previous.Version
+	}
+
+	diff := services.DiffRegularSchedules(before, after)
+	diff.From = from
+	return diff
+}
+
+// newAuditEntry создаёт запись аудита с данными о клиенте запроса
+func newAuditEntry(c *gin.Context, action string) models.AuditEntry {
+	entry := models.AuditEntry{
+		Action:   action,
+		Actor:    "anonymous",
+		ClientIP: c.ClientIP(),
+	}
+	if principal := middleware.CurrentPrincipal(c); principal != nil {
+		entry.Actor = principal.Subject
+		entry.AuthMethod = principal.AuthMethod
+	}
+	return entry
+}
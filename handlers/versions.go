@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFileVersions возвращает историю версий файла расписания, от новых к старым
+func (h *ScheduleHandler) GetFileVersions(c *gin.Context) {
+	university := c.Param("university")
+	course := c.Param("course")
+	scheduleType := c.Param("type")
+	fileName := c.Param("filename")
+
+	objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
+
+	versions, err := h.minioService.ListObjectVersions(c.Request.Context(), objectPath)
+	if err != nil {
+		respondStorageError(c, "failed to list file versions", err)
+		return
+	}
+
+	if len(versions) == 0 {
+		respondError(c, models.ErrCodeFileNotFound)
+		return
+	}
+
+	respond(c, http.StatusOK, versions, models.TotalMeta(len(versions)), false)
+}
+
+// GetFileVersionContent отдаёт содержимое указанной версии файла
+func (h *ScheduleHandler) GetFileVersionContent(c *gin.Context) {
+	university := c.Param("university")
+	course := c.Param("course")
+	scheduleType := c.Param("type")
+	fileName := c.Param("filename")
+	version := c.Param("version")
+
+	objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
+
+	data, contentType, err := h.minioService.DownloadVersion(c.Request.Context(), objectPath, version)
+	if err != nil {
+		respondStorageError(c, "failed to download file version", err)
+		return
+	}
+
+	if data == nil {
+		respondError(c, models.ErrCodeVersionNotFound)
+		return
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("X-Object-Version", version)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// RestoreFileVersion делает старую версию файла текущей и сбрасывает кэш списка файлов
+func (h *ScheduleHandler) RestoreFileVersion(c *gin.Context) {
+	university := c.Param("university")
+	course := c.Param("course")
+	scheduleType := c.Param("type")
+	fileName := c.Param("filename")
+	version := c.Param("version")
+
+	entry := newAuditEntry(c, models.AuditActionRestoreVersion)
+	entry.University = university
+	entry.Course = course
+	entry.ScheduleType = scheduleType
+	entry.File = fileName
+	entry.Version = version
+	entry.Result = models.AuditResultFailure
+	defer func() {
+		if err := h.auditService.Record(c.Request.Context(), entry); err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to write audit log", "error", err)
+		}
+	}()
+
+	objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
+	entry.TargetPath = objectPath
+
+	// Запоминаем, какую версию заменяем
+	if current, err := h.minioService.StatObjectInBucket(c.Request.Context(), h.minioService.Bucket(), objectPath); err == nil && current != nil {
+		entry.ReplacedETag = current.ETag
+	}
+
+	restored, err := h.minioService.RestoreVersion(c.Request.Context(), objectPath, version)
+	if err != nil {
+		entry.Error = err.Error()
+		respondStorageError(c, "failed to restore file version", err)
+		return
+	}
+
+	if restored == nil {
+		entry.Error = "file version not found"
+		respondError(c, models.ErrCodeVersionNotFound)
+		return
+	}
+
+	// Инвалидируем кэш для этого расписания
+	cacheKey := fmt.Sprintf("files:%s:%s:%s", university, course, scheduleType)
+	h.cacheService.DeleteList(cacheKey)
+	h.cacheService.Delete(examScheduleCacheKey(university, course, scheduleType, fileName))
+
+	h.events.Publish(models.Event{
+		Type:         models.EventVersionRestored,
+		University:   university,
+		Course:       course,
+		ScheduleType: scheduleType,
+		File:         fileName,
+		Version:      restored.Version,
+	})
+
+	entry.Result = models.AuditResultSuccess
+	logging.FromContext(c.Request.Context()).Info("file version restored",
+		"target", objectPath,
+		"restored_version", version,
+		"new_version", restored.Version,
+	)
+
+	respond(c, http.StatusOK, models.RestoredVersion{RestoredVersion: version, File: restored}, nil, false)
+}
+
+// GetFileDiff возвращает изменения занятий между версиями from и to основного расписания.
+// По умолчанию to — текущая версия, from — предшествующая ей
+func (h *ScheduleHandler) GetFileDiff(c *gin.Context) {
+	university := c.Param("university")
+	course := c.Param("course")
+	scheduleType := c.Param("type")
+	fileName := c.Param("filename")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	ctx := c.Request.Context()
+	objectPath := h.layouts.For(university).ObjectPath(university, course, scheduleType, fileName)
+
+	if from == "" || to == "" {
+		versions, err := h.minioService.ListObjectVersions(ctx, objectPath)
+		if err != nil {
+			respondStorageError(c, "failed to list file versions", err)
+			return
+		}
+		to, from = resolveDiffVersions(versions, from, to)
+		if to == "" {
+			respondError(c, models.ErrCodeVersionNotFound)
+			return
+		}
+	}
+
+	// Разница, сохранённая при обработке, уже описывает переход к версии to
+	stored, err := h.diffService.Load(ctx, objectPath, to)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to load stored diff", "target", objectPath, "version", to, "error", err)
+	}
+	if stored != nil && (from == "" || stored.From == from) {
+		respond(c, http.StatusOK, stored, nil, false)
+		return
+	}
+
+	if from == "" {
+		respondError(c, models.ErrCodeNoPreviousVersion)
+		return
+	}
+
+	diff, err := h.diffService.Compare(ctx, objectPath, from, to)
+	if errors.Is(err, services.ErrDiffUnsupported) {
+		respondError(c, models.ErrCodeDiffUnsupported)
+		return
+	}
+	if err != nil {
+		respondStorageError(c, "failed to compare file versions", err)
+		return
+	}
+	if diff == nil {
+		respondError(c, models.ErrCodeVersionNotFound)
+		return
+	}
+
+	diff.University = university
+	diff.Course = course
+	diff.ScheduleType = scheduleType
+	diff.File = fileName
+
+	respond(c, http.StatusOK, diff, nil, false)
+}
+
+// resolveDiffVersions подставляет версии по умолчанию: to — текущая, from — предшествующая to.
+// versions отсортированы от новых к старым; удалённые версии пропускаются
+func resolveDiffVersions(versions []models.FileVersion, from, to string) (string, string) {
+	found := to == ""
+	for _, version := range versions {
+		if version.IsDeleteMarker {
+			continue
+		}
+		if to == "" {
+			to = version.Version
+			continue
+		}
+		if found && from == "" {
+			from = version.Version
+			break
+		}
+		if version.Version == to {
+			found = true
+		}
+	}
+	return to, from
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/internal/s3test"
+	"schedule-api/layout"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResolveDiffVersions(t *testing.T) {
+	versions := []models.FileVersion{
+		{Version: "v4", IsLatest: true},
+		{Version: "v3", IsDeleteMarker: true},
+		{Version: "v2"},
+		{Version: "v1"},
+	}
+
+	tests := []struct {
+		name     string
+		versions []models.FileVersion
+		from     string
+		to       string
+		wantTo   string
+		wantFrom string
+	}{
+		{name: "defaults", versions: versions, wantTo: "v4", wantFrom: "v2"},
+		{name: "to given", versions: versions, to: "v2", wantTo: "v2", wantFrom: "v1"},
+		{name: "from given", versions: versions, from: "v1", wantTo: "v4", wantFrom: "v1"},
+		{name: "oldest version has no predecessor", versions: versions, to: "v1", wantTo: "v1"},
+		{name: "unknown to", versions: versions, to: "v9", wantTo: "v9"},
+		{name: "single version", versions: versions[:1], wantTo: "v4"},
+		{name: "only delete markers", versions: []models.FileVersion{{Version: "v3", IsDeleteMarker: true}}},
+		{name: "no versions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, from := resolveDiffVersions(tt.versions, tt.from, tt.to)
+			if to != tt.wantTo || from != tt.wantFrom {
+				t.Errorf("resolveDiffVersions = (%q, %q), want (%q, %q)", to, from, tt.wantTo, tt.wantFrom)
+			}
+		})
+	}
+}
+
+// scheduleTestEnv — обработчик расписаний поверх хранилища в памяти: расписания лежат в бакете
+// schedules (TARGET_BUCKET), журнал аудита — в schedule-api
+type scheduleTestEnv struct {
+	server  *s3test.Server
+	cache   *services.CacheService
+	events  *services.EventBus
+	handler *ScheduleHandler
+	router  *gin.Engine
+}
+
+func newScheduleTestEnv(t *testing.T) *scheduleTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	server := s3test.NewServer(t, "schedule-api", "schedules")
+	minio, err := services.NewMinIOService(&config.Config{
+		MinIOEndpoint: server.Endpoint(),
+		MinIOBucket:   "schedule-api",
+		TargetBucket:  "schedules",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layouts, err := layout.NewResolver("{university}/{course}/{type}/{file}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &scheduleTestEnv{
+		server: server,
+		cache:  services.NewCacheService(time.Hour, time.Hour),
+		events: services.NewEventBus(10),
+	}
+	env.handler = NewScheduleHandler(minio, env.cache,
+		services.NewAuditService(minio, "schedule-api", "audit"),
+		services.NewDiffService(minio, "schedule-api", "diffs"),
+		env.events, layouts)
+
+	env.router = gin.New()
+	files := env.router.Group("/universities/:university/courses/:course/types/:type/files/:filename")
+	files.POST("/versions/:version/restore", env.handler.RestoreFileVersion)
+	files.GET("/diff", env.handler.GetFileDiff)
+	files.GET("/groups/:group/exams", env.handler.GetGroupExamSession)
+	return env
+}
+
+func (env *scheduleTestEnv) do(method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRestoreFileVersion(t *testing.T) {
+	env := newScheduleTestEnv(t)
+	const path = "kgu/1/regular/a.json"
+	v1 := env.server.Put("schedules", path, []byte(`{"v":1}`))
+	env.server.Put("schedules", path, []byte(`{"v":2}`))
+
+	env.cache.Set("files:kgu:1:regular", []models.ScheduleFile{{Name: "a.json"}}, 0)
+	sub, _, _ := env.events.Subscribe(models.EventFilter{}, "")
+	defer env.events.Unsubscribe(sub)
+
+	w := env.do(http.MethodPost, "/universities/kgu/courses/1/types/regular/files/a.json/versions/"+v1+"/restore")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body models.Response[models.RestoredVersion]
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.RestoredVersion != v1 || body.Data.File == nil || body.Data.File.Version == v1 || !body.Data.File.IsLatest {
+		t.Errorf("data = %+v", body.Data)
+	}
+
+	if data, _ := env.server.Get("schedules", path); string(data) != `{"v":1}` {
+		t.Errorf("current content = %s, want version %s", data, v1)
+	}
+	if env.cache.Contains("files:kgu:1:regular") {
+		t.Error("file list cache was not invalidated")
+	}
+	select {
+	case event := <-sub.C:
+		if event.Type != models.EventVersionRestored || event.Version != body.Data.File.Version {
+			t.Errorf("event = %+v", event)
+		}
+	default:
+		t.Error("no version restored event")
+	}
+
+	audit := env.server.Objects("schedule-api")
+	if len(audit) != 1 || !strings.HasPrefix(audit[0], "audit/") {
+		t.Fatalf("audit objects = %v", audit)
+	}
+	data, _ := env.server.Get("schedule-api", audit[0])
+	var entry models.AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Action != models.AuditActionRestoreVersion ||
+		entry.Result != models.AuditResultSuccess || entry.Version != v1 || entry.ReplacedETag == "" {
+		t.Errorf("audit entry = %s", data)
+	}
+}
+
+func TestRestoreMissingVersion(t *testing.T) {
+	env := newScheduleTestEnv(t)
+	env.server.Put("schedules", "kgu/1/regular/a.json", []byte(`{"v":1}`))
+
+	w := env.do(http.MethodPost, "/universities/kgu/courses/1/types/regular/files/a.json/versions/missing/restore")
+	var body models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusNotFound || body.Code != models.ErrCodeVersionNotFound {
+		t.Errorf("got %d %s, want 404 %s", w.Code, w.Body.String(), models.ErrCodeVersionNotFound)
+	}
+
+	// Неудачная попытка тоже попадает в журнал аудита
+	audit := env.server.Objects("schedule-api")
+	if len(audit) != 1 {
+		t.Fatalf("audit objects = %v", audit)
+	}
+	data, _ := env.server.Get("schedule-api", audit[0])
+	if !strings.Contains(string(data), `"result":"failure"`) {
+		t.Errorf("audit entry = %s", data)
+	}
+}
+
+func TestGetFileDiffFromTargetBucket(t *testing.T) {
+	env := newScheduleTestEnv(t)
+	schedule := func(subject string) []byte {
+		data, _ := json.Marshal(models.RegularSchedule{Type: "regular", Groups: []models.GroupSchedule{{
+			GroupNumber: "23101",
+			Days: []models.DaySchedule{{DayOfWeek: "Понедельник", Lessons: []models.Lesson{
+				{Time: "08:00-09:30", Subject: subject, Teacher: "Гареева Г.А."},
+			}}},
+		}}})
+		return data
+	}
+	const path = "kgu/1/regular/a.json"
+	v1 := env.server.Put("schedules", path, schedule("Физика"))
+	v2 := env.server.Put("schedules", path, schedule("Химия"))
+
+	// Сохранённой разницы нет: версии по умолчанию сравниваются заново
+	w := env.do(http.MethodGet, "/universities/kgu/courses/1/types/regular/files/a.json/diff")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body models.Response[models.ScheduleDiff]
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.From != v1 || body.Data.To != v2 || body.Data.Summary.Modified != 1 || body.Data.File != "a.json" {
+		t.Errorf("diff = %+v", body.Data)
+	}
+
+	w = env.do(http.MethodGet, "/universities/kgu/courses/1/types/regular/files/a.json/diff?to="+v1)
+	var errBody models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errBody); err != nil || errBody.Code != models.ErrCodeNoPreviousVersion {
+		t.Errorf("diff of the first version: %d %s, want %s", w.Code, w.Body.String(), models.ErrCodeNoPreviousVersion)
+	}
+}
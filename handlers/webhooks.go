@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"schedule-api/logging"
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const webhookDeliveriesMaxLimit = 1000
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	auditService   *services.AuditService
+	httpsOnly      bool // В продакшне уведомления отправляются только по https
+}
+
+func NewWebhookHandler(webhooks *services.WebhookService, audit *services.AuditService, httpsOnly bool) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhooks,
+		auditService:   audit,
+		httpsOnly:      httpsOnly,
+	}
+}
+
+// GetSubscriptions возвращает подписки на webhook'и (без секретов).
+// Администратор университета видит только подписки своих университетов
+func (h *WebhookHandler) GetSubscriptions(c *gin.Context) {
+	all, err := h.webhookService.Subscriptions(c.Request.Context())
+	if err != nil {
+		respondStorageError(c, "failed to load webhook subscriptions", err)
+		return
+	}
+
+	principal := middleware.CurrentPrincipal(c)
+	subscriptions := make([]models.WebhookSubscription, 0, len(all))
+	for _, subscription := range all {
+		if canManageWebhook(principal, subscription.University) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	respond(c, http.StatusOK, subscriptions, models.TotalMeta(len(subscriptions)), false)
+}
+
+// CreateSubscription регистрирует подписку. Секрет подписи возвращается только в этом ответе
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req models.WebhookSubscription
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, models.ErrCodeInvalidRequestBody, bindingErrorDetail(err))
+		return
+	}
+	if !h.urlAllowed(req.URL) {
+		detail := "url must use http or https"
+		if h.httpsOnly {
+			detail = "url must use https"
+		}
+		respondError(c, models.ErrCodeInvalidRequestBody, detail)
+		return
+	}
+	// Подписка без университета получает изменения всех университетов
+	if principal := middleware.CurrentPrincipal(c); !canManageWebhook(principal, req.University) {
+		if req.University == "" {
+			respondError(c, models.ErrCodeAllUniversitiesRequired)
+		} else {
+			respondError(c, models.ErrCodeUniversityAccessDenied, req.University)
+		}
+		return
+	}
+
+	entry := newAuditEntry(c, models.AuditActionWebhookCreate)
+	req.CreatedBy = entry.Actor
+	entry.University = req.University
+	entry.Course = req.Course
+	entry.TargetPath = req.URL
+	entry.Result = models.AuditResultFailure
+	defer h.recordAudit(c, &entry)
+
+	subscription, err := h.webhookService.Subscribe(c.Request.Context(), req)
+	if err != nil {
+		entry.Error = err.Error()
+		respondStorageError(c, "failed to create webhook subscription", err)
+		return
+	}
+	entry.Webhook = subscription.ID
+	entry.Result = models.AuditResultSuccess
+
+	respond(c, http.StatusCreated, subscription, nil, false)
+}
+
+// DeleteSubscription удаляет подписку. Чужие для администратора университета подписки
+// считаются несуществующими
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id := c.Param("id")
+
+	entry := newAuditEntry(c, models.AuditActionWebhookDelete)
+	entry.Webhook = id
+	entry.Result = models.AuditResultFailure
+	defer h.recordAudit(c, &entry)
+
+	subscriptions, err := h.webhookService.Subscriptions(c.Request.Context())
+	if err != nil {
+		entry.Error = err.Error()
+		respondStorageError(c, "failed to load webhook subscriptions", err)
+		return
+	}
+	index := slices.IndexFunc(subscriptions, func(s models.WebhookSubscription) bool { return s.ID == id })
+	if index >= 0 {
+		entry.University = subscriptions[index].University
+		if !canManageWebhook(middleware.CurrentPrincipal(c), entry.University) {
+			entry.Result = models.AuditResultDenied
+			respondError(c, models.ErrCodeWebhookNotFound)
+			return
+		}
+	}
+
+	deleted, err := h.webhookService.Unsubscribe(c.Request.Context(), id)
+	if err != nil {
+		entry.Error = err.Error()
+		respondStorageError(c, "failed to delete webhook subscription", err)
+		return
+	}
+	if !deleted {
+		entry.Error = "subscription not found"
+		respondError(c, models.ErrCodeWebhookNotFound)
+		return
+	}
+	entry.Result = models.AuditResultSuccess
+
+	respond(c, http.StatusOK, models.Message{Message: "webhook subscription deleted successfully"}, nil, false)
+}
+
+// GetDeliveries возвращает журнал доставок. Фильтры: subscription, status, limit.
+// Журнал хранится в памяти и после перезапуска начинается заново
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > webhookDeliveriesMaxLimit {
+			respondError(c, models.ErrCodeInvalidLimit, webhookDeliveriesMaxLimit)
+			return
+		}
+	}
+
+	deliveries := h.webhookService.Deliveries(c.Query("subscription"), c.Query("status"), limit)
+	respond(c, http.StatusOK, deliveries, models.TotalMeta(len(deliveries)), false)
+}
+
+// GetDeadLetters возвращает доставки, для которых исчерпаны попытки; хранятся в памяти до перезапуска
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	deadLetters := h.webhookService.DeadLetters()
+	respond(c, http.StatusOK, deadLetters, models.TotalMeta(len(deadLetters)), false)
+}
+
+// RetryDeadLetter повторно отправляет доставку из dead-letter
+func (h *WebhookHandler) RetryDeadLetter(c *gin.Context) {
+	scheduled, err := h.webhookService.Redeliver(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondStorageError(c, "failed to load webhook subscriptions", err)
+		return
+	}
+	if !scheduled {
+		respondError(c, models.ErrCodeDeadLetterNotFound)
+		return
+	}
+
+	respond(c, http.StatusAccepted, models.Message{Message: "delivery scheduled"}, nil, false)
+}
+
+// urlAllowed проверяет схему адреса подписки: другие схемы http.Client не отправит
+func (h *WebhookHandler) urlAllowed(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "https" || (u.Scheme == "http" && !h.httpsOnly)
+}
+
+// canManageWebhook: подписка без университета касается всех университетов,
+// поэтому управлять ею может только клиент без ограничения области
+func canManageWebhook(principal *models.Principal, university string) bool {
+	if university == "" {
+		return principal.AllUniversities()
+	}
+	return principal.CanAccessUniversity(university)
+}
+
+func (h *WebhookHandler) recordAudit(c *gin.Context, entry *models.AuditEntry) {
+	if err := h.auditService.Record(c.Request.Context(), *entry); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to write audit log", "error", err)
+	}
+}
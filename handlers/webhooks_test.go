@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	"schedule-api/models"
+)
+
+func TestCanManageWebhook(t *testing.T) {
+	global := &models.Principal{Role: models.RoleAdmin}
+	scoped := &models.Principal{Role: models.RoleAdmin, Universities: []string{"kgu"}}
+
+	tests := []struct {
+		name       string
+		principal  *models.Principal
+		university string
+		want       bool
+	}{
+		{name: "global admin, all universities", principal: global, university: "", want: true},
+		{name: "global admin, one university", principal: global, university: "agtu", want: true},
+		{name: "scoped admin, own university", principal: scoped, university: "kgu", want: true},
+		{name: "scoped admin, other university", principal: scoped, university: "agtu", want: false},
+		{name: "scoped admin, all universities", principal: scoped, university: "", want: false},
+		{name: "no principal", principal: nil, university: "kgu", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := canManageWebhook(tt.principal, tt.university); got != tt.want {
+			t.Errorf("%s: canManageWebhook = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"schedule-api/logging"
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Сообщения WebSocket-канала
+const (
+	wsActionSubscribe   = "subscribe"
+	wsActionUnsubscribe = "unsubscribe"
+	wsActionPing        = "ping"
+
+	wsMessageSubscriptions     = "subscriptions"
+	wsMessageScheduleChanged   = "schedule.changed"
+	wsMessageReplacementsToday = "replacement.today"
+	wsMessagePong              = "pong"
+	wsMessageError             = "error"
+)
+
+const (
+	wsMaxMessageSize = 4 << 10
+	wsWriteTimeout   = 10 * time.Second
+)
+
+// wsRequest — сообщение клиента: {"action":"subscribe","groups":["ИС-21"],"teachers":["Иванов И.И."]}
+type wsRequest struct {
+	Action   string   `json:"action"`
+	Groups   []string `json:"groups"`
+	Teachers []string `json:"teachers"`
+}
+
+// wsMessage — сообщение сервера
+type wsMessage struct {
+	Type     string        `json:"type"`
+	Event    *models.Event `json:"event,omitempty"`
+	Groups   []string      `json:"groups,omitempty"`
+	Teachers []string      `json:"teachers,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type WebSocketHandler struct {
+	bus              *services.EventBus
+	upgrader         websocket.Upgrader
+	heartbeat        time.Duration
+	maxSubscriptions int
+}
+
+func NewWebSocketHandler(bus *services.EventBus, cors *middleware.CORSPolicy, heartbeat time.Duration, maxSubscriptions int) *WebSocketHandler {
+	return &WebSocketHandler{
+		bus: bus,
+		upgrader: websocket.Upgrader{
+			// Мобильные клиенты не присылают Origin, браузерные проверяем по политике CORS
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || cors.AllowsOrigin(origin)
+			},
+		},
+		heartbeat:        heartbeat,
+		maxSubscriptions: maxSubscriptions,
+	}
+}
+
+// Connect открывает WebSocket-канал обновлений. Клиент подписывается на группы и преподавателей
+// сообщениями subscribe/unsubscribe и получает schedule.changed и replacement.today.
+// Необязательные параметры university и course сужают канал до одного университета и курса
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader уже ответил клиенту
+		logging.FromContext(c.Request.Context()).Debug("websocket upgrade failed", "error", err)
+		return
+	}
+
+	sub, _, _ := h.bus.Subscribe(models.EventFilter{
+		University: c.Query("university"),
+		Course:     c.Query("course"),
+		Types:      []string{models.EventScheduleProcessed, models.EventVersionRestored},
+	}, "")
+
+	session := &wsSession{
+		conn:             conn,
+		maxSubscriptions: h.maxSubscriptions,
+		groups:           make(map[string]bool),
+		teachers:         make(map[string]bool),
+	}
+	logger := logging.FromContext(c.Request.Context())
+	logger.Debug("websocket connected")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		session.readLoop(h.heartbeat)
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	defer func() {
+		h.bus.Unsubscribe(sub)
+		conn.Close()
+		<-done
+		logger.Debug("websocket disconnected")
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Шина остановлена или клиент не успевал читать — клиент переподключится
+				session.close(websocket.CloseGoingAway, "reconnect required")
+				return
+			}
+			if err := session.deliver(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := session.ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// wsSession — состояние одного соединения. Писать в conn может только один поток,
+// поэтому все записи идут под writeMu
+type wsSession struct {
+	conn             *websocket.Conn
+	maxSubscriptions int
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	groups   map[string]bool
+	teachers map[string]bool // Ключ — TeacherKey имени или ID преподавателя
+}
+
+// readLoop обрабатывает сообщения клиента, пока соединение открыто.
+// Клиент, не ответивший на два ping подряд, считается отключившимся
+func (s *wsSession) readLoop(heartbeat time.Duration) {
+	s.conn.SetReadLimit(wsMaxMessageSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			_ = s.send(wsMessage{Type: wsMessageError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+
+		switch req.Action {
+		case wsActionSubscribe:
+			if err := s.subscribe(req.Groups, req.Teachers); err != nil {
+				_ = s.send(wsMessage{Type: wsMessageError, Error: err.Error()})
+				continue
+			}
+			_ = s.send(s.subscriptions())
+		case wsActionUnsubscribe:
+			s.unsubscribe(req.Groups, req.Teachers)
+			_ = s.send(s.subscriptions())
+		case wsActionPing:
+			_ = s.send(wsMessage{Type: wsMessagePong})
+		default:
+			_ = s.send(wsMessage{Type: wsMessageError, Error: fmt.Sprintf("unknown action %q", req.Action)})
+		}
+	}
+}
+
+// subscribe добавляет подписки целиком или не добавляет ни одной, если превышен лимит
+func (s *wsSession) subscribe(groups, teachers []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, group := range groups {
+		if group = strings.TrimSpace(group); group != "" && !s.groups[group] {
+			added++
+		}
+	}
+	for _, teacher := range teachers {
+		if teacher = models.TeacherKey(teacher); teacher != "" && !s.teachers[teacher] {
+			added++
+		}
+	}
+	if len(s.groups)+len(s.teachers)+added > s.maxSubscriptions {
+		return fmt.Errorf("subscription limit exceeded: at most %d groups and teachers per connection", s.maxSubscriptions)
+	}
+
+	for _, group := range groups {
+		if group = strings.TrimSpace(group); group != "" {
+			s.groups[group] = true
+		}
+	}
+	for _, teacher := range teachers {
+		if teacher = models.TeacherKey(teacher); teacher != "" {
+			s.teachers[teacher] = true
+		}
+	}
+	return nil
+}
+
+func (s *wsSession) unsubscribe(groups, teachers []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, group := range groups {
+		delete(s.groups, strings.TrimSpace(group))
+	}
+	for _, teacher := range teachers {
+		delete(s.teachers, models.TeacherKey(teacher))
+	}
+}
+
+func (s *wsSession) subscriptions() wsMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := wsMessage{Type: wsMessageSubscriptions, Groups: make([]string, 0), Teachers: make([]string, 0)}
+	for group := range s.groups {
+		msg.Groups = append(msg.Groups, group)
+	}
+	for teacher := range s.teachers {
+		msg.Teachers = append(msg.Teachers, teacher)
+	}
+	slices.Sort(msg.Groups)
+	slices.Sort(msg.Teachers)
+	return msg
+}
+
+// deliver отправляет событие, если оно касается подписок соединения
+func (s *wsSession) deliver(event models.Event) error {
+	if !s.interested(event) {
+		return nil
+	}
+
+	msgType := wsMessageScheduleChanged
+	if slices.Contains(event.Dates, time.Now().Format("2006-01-02")) {
+		msgType = wsMessageReplacementsToday
+	}
+	return s.send(wsMessage{Type: msgType, Event: &event})
+}
+
+// interested: событие доставляется по пересечению его групп и преподавателей с подписками.
+// Преподаватель в подписке задаётся именем в любом написании или ID из справочника;
+// событие без области (откат версии, сброс кэша) — всем, у кого есть хоть одна подписка
+func (s *wsSession) interested(event models.Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.groups) == 0 && len(s.teachers) == 0 {
+		return false
+	}
+	if event.Unscoped() {
+		return true
+	}
+	for _, group := range event.Groups {
+		if s.groups[group] {
+			return true
+		}
+	}
+	for _, teacher := range event.Teachers {
+		for subscribed := range s.teachers {
+			if models.SameTeacher(teacher, subscribed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *wsSession) send(msg wsMessage) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return s.conn.WriteJSON(msg)
+}
+
+func (s *wsSession) ping() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+}
+
+func (s *wsSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"schedule-api/middleware"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func newTestSession(maxSubscriptions int) *wsSession {
+	return &wsSession{
+		maxSubscriptions: maxSubscriptions,
+		groups:           make(map[string]bool),
+		teachers:         make(map[string]bool),
+	}
+}
+
+func TestWebSocketSubscriptions(t *testing.T) {
+	s := newTestSession(3)
+
+	if err := s.subscribe([]string{" 23101 ", ""}, []string{"Гареева  Г. А"}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	// Повторная подписка в другом написании не занимает лимит
+	if err := s.subscribe([]string{"23101"}, []string{"гареева г.а."}); err != nil {
+		t.Fatalf("repeated subscribe: %v", err)
+	}
+
+	msg := s.subscriptions()
+	if !slices.Equal(msg.Groups, []string{"23101"}) || !slices.Equal(msg.Teachers, []string{"гареева г.а."}) {
+		t.Errorf("subscriptions = %v %v", msg.Groups, msg.Teachers)
+	}
+
+	// Превышение лимита отклоняет запрос целиком
+	if err := s.subscribe([]string{"23102", "23103"}, nil); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("subscribe over limit: err = %v", err)
+	}
+	if msg := s.subscriptions(); len(msg.Groups) != 1 {
+		t.Errorf("partial subscription applied: %v", msg.Groups)
+	}
+
+	s.unsubscribe([]string{"23101"}, []string{"Гареева Г.А."})
+	if msg := s.subscriptions(); len(msg.Groups) != 0 || len(msg.Teachers) != 0 {
+		t.Errorf("after unsubscribe: %v %v", msg.Groups, msg.Teachers)
+	}
+	if err := s.subscribe([]string{"23102", "23103"}, []string{"Иванов И.И."}); err != nil {
+		t.Errorf("unsubscribe did not free the limit: %v", err)
+	}
+}
+
+func TestWebSocketInterested(t *testing.T) {
+	processed := func(groups, teachers []string) models.Event {
+		return models.Event{Type: models.EventScheduleProcessed, Groups: groups, Teachers: teachers}
+	}
+
+	tests := []struct {
+		name     string
+		groups   []string
+		teachers []string
+		event    models.Event
+		want     bool
+	}{
+		{name: "no subscriptions", event: processed([]string{"23101"}, nil), want: false},
+		{name: "group", groups: []string{"23101"}, event: processed([]string{"23102", "23101"}, nil), want: true},
+		{name: "other group", groups: []string{"23101"}, event: processed([]string{"23102"}, nil), want: false},
+		{name: "teacher by name", teachers: []string{"Гареева Г. А."}, event: processed(nil, []string{"Гареева Г.А."}), want: true},
+		{name: "teacher by ID", teachers: []string{models.TeacherID("Гареева Г.А.")}, event: processed(nil, []string{"Гареева Г.А."}), want: true},
+		{name: "surname only", teachers: []string{"Иванов"}, event: processed(nil, []string{"Иванова И.И."}), want: false},
+		{name: "event without scope", groups: []string{"23101"}, event: models.Event{Type: models.EventVersionRestored}, want: true},
+		{name: "processed event without groups", groups: []string{"23101"}, event: processed(nil, nil), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(10)
+			if err := s.subscribe(tt.groups, tt.teachers); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.interested(tt.event); got != tt.want {
+				t.Errorf("interested = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := services.NewEventBus(10)
+	cors := middleware.NewCORSPolicy(middleware.CORSOptions{AllowedOrigins: []string{"*"}})
+	h := NewWebSocketHandler(bus, cors, time.Minute, 10)
+
+	router := gin.New()
+	router.GET("/ws", h.Connect)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?university=kgu", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	read := func() wsMessage {
+		t.Helper()
+		var msg wsMessage
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return msg
+	}
+
+	if err := conn.WriteJSON(wsRequest{Action: wsActionSubscribe, Teachers: []string{models.TeacherID("Петров П.П.")}}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := read(); msg.Type != wsMessageSubscriptions || len(msg.Teachers) != 1 {
+		t.Fatalf("got %+v, want subscriptions", msg)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	bus.Publish(models.Event{Type: models.EventScheduleProcessed, University: "agtu", Teachers: []string{"Петров П.П."}, Dates: []string{today}})
+	bus.Publish(models.Event{Type: models.EventScheduleProcessed, University: "kgu", Teachers: []string{"Иванов И.И."}, Dates: []string{today}})
+	bus.Publish(models.Event{Type: models.EventScheduleProcessed, University: "kgu", File: "zameny.json", Teachers: []string{"Петров П.П."}, Dates: []string{today}})
+
+	// События другого университета и других преподавателей не доставляются
+	msg := read()
+	if msg.Type != wsMessageReplacementsToday || msg.Event == nil || msg.Event.File != "zameny.json" {
+		t.Fatalf("got %+v, want replacement.today for zameny.json", msg)
+	}
+
+	if err := conn.WriteJSON(wsRequest{Action: "resubscribe"}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := read(); msg.Type != wsMessageError {
+		t.Errorf("unknown action: got %+v, want error", msg)
+	}
+}
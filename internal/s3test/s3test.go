@@ -0,0 +1,387 @@
+// Package s3test — хранилище S3 в памяти для тестов кода, работающего с MinIO.
+// Поддерживает то, что использует сервис: версионируемые объекты, копирование версии,
+// листинги объектов и версий. Подпись запросов не проверяется
+package s3test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server — S3-совместимый сервер; все бакеты версионируемые
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	buckets  map[string][]*object // Версии объектов в порядке загрузки
+	versions int
+	requests []string
+}
+
+type object struct {
+	key         string
+	versionID   string
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+func (o *object) etag() string {
+	return fmt.Sprintf("%q", "etag-"+o.versionID)
+}
+
+// NewServer запускает сервер с пустыми бакетами buckets; сервер останавливается по окончании теста
+func NewServer(t testing.TB, buckets ...string) *Server {
+	s := &Server{buckets: make(map[string][]*object)}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = nil
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Endpoint возвращает адрес сервера в формате MINIO_ENDPOINT
+func (s *Server) Endpoint() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Put сохраняет новую версию объекта и возвращает её идентификатор
+func (s *Server) Put(bucket, key string, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(bucket, key, data, "application/octet-stream").versionID
+}
+
+// Objects возвращает ключи текущих версий объектов бакета по возрастанию
+func (s *Server) Objects(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0)
+	for _, o := range s.latest(bucket) {
+		keys = append(keys, o.key)
+	}
+	return keys
+}
+
+// Get возвращает содержимое текущей версии объекта
+func (s *Server) Get(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.find(bucket, key, "")
+	if o == nil {
+		return nil, false
+	}
+	return o.data, true
+}
+
+// Requests возвращает выполненные запросы в виде "METHOD bucket/key"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// put вызывается под s.mu
+func (s *Server) put(bucket, key string, data []byte, contentType string) *object {
+	s.versions++
+	o := &object{
+		key:         key,
+		versionID:   "v" + strconv.Itoa(s.versions),
+		data:        data,
+		contentType: contentType,
+		modified:    time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC).Add(time.Duration(s.versions) * time.Minute),
+	}
+	s.buckets[bucket] = append(s.buckets[bucket], o)
+	return o
+}
+
+// find ищет версию объекта; пустой versionID — текущая версия. Вызывается под s.mu
+func (s *Server) find(bucket, key, versionID string) *object {
+	objects := s.buckets[bucket]
+	for i := len(objects) - 1; i >= 0; i-- {
+		o := objects[i]
+		if o.key == key && (versionID == "" || o.versionID == versionID) {
+			return o
+		}
+	}
+	return nil
+}
+
+// latest возвращает текущие версии объектов бакета по возрастанию ключа; вызывается под s.mu
+func (s *Server) latest(bucket string) []*object {
+	current := make(map[string]*object)
+	for _, o := range s.buckets[bucket] {
+		current[o.key] = o
+	}
+	objects := make([]*object, 0, len(current))
+	for _, o := range current {
+		objects = append(objects, o)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].key < objects[j].key })
+	return objects
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+strings.TrimSuffix(bucket+"/"+key, "/"))
+
+	if _, ok := s.buckets[bucket]; !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", bucket, key)
+		return
+	}
+
+	switch {
+	case key == "" && query.Has("location"):
+		writeXML(w, http.StatusOK, locationConstraint{Location: "us-east-1"})
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && query.Has("versions"):
+		s.listVersions(w, bucket, query)
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, bucket, query)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucket, key)
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody", bucket, key)
+			return
+		}
+		o := s.put(bucket, key, data, r.Header.Get("Content-Type"))
+		w.Header().Set("ETag", o.etag())
+		w.Header().Set("X-Amz-Version-Id", o.versionID)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		o := s.find(bucket, key, query.Get("versionId"))
+		if o == nil {
+			code := "NoSuchKey"
+			if query.Get("versionId") != "" && s.find(bucket, key, "") != nil {
+				code = "NoSuchVersion"
+			}
+			writeError(w, r, http.StatusNotFound, code, bucket, key)
+			return
+		}
+		w.Header().Set("Content-Type", o.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+		w.Header().Set("ETag", o.etag())
+		w.Header().Set("Last-Modified", o.modified.Format(http.TimeFormat))
+		w.Header().Set("X-Amz-Version-Id", o.versionID)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(o.data)
+		}
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", bucket, key)
+	}
+}
+
+// copyObject копирует версию из X-Amz-Copy-Source ("/bucket/key?versionId=...") в новую версию
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	source, rawQuery, _ := strings.Cut(r.Header.Get("X-Amz-Copy-Source"), "?")
+	source, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", bucket, key)
+		return
+	}
+	sourceQuery, _ := url.ParseQuery(rawQuery)
+	sourceBucket, sourceKey, _ := strings.Cut(source, "/")
+
+	src := s.find(sourceBucket, sourceKey, sourceQuery.Get("versionId"))
+	if src == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchVersion", sourceBucket, sourceKey)
+		return
+	}
+
+	o := s.put(bucket, key, slices.Clone(src.data), src.contentType)
+	w.Header().Set("ETag", o.etag())
+	w.Header().Set("X-Amz-Version-Id", o.versionID)
+	writeXML(w, http.StatusOK, copyObjectResult{LastModified: o.modified, ETag: o.etag()})
+}
+
+// listObjects отвечает на ListObjectsV2: текущие версии с учётом prefix, delimiter и позиции
+func (s *Server) listObjects(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	after := max(query.Get("start-after"), query.Get("continuation-token"))
+	maxKeys := 1000
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil && n > 0 {
+		maxKeys = n
+	}
+
+	result := listBucketResult{Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys}
+	seen := make(map[string]bool)
+	for _, o := range s.latest(bucket) {
+		if !strings.HasPrefix(o.key, prefix) {
+			continue
+		}
+		name, isPrefix := o.key, false
+		if delimiter != "" {
+			if i := strings.Index(o.key[len(prefix):], delimiter); i >= 0 {
+				name, isPrefix = o.key[:len(prefix)+i+len(delimiter)], true
+			}
+		}
+		if name <= after || seen[name] {
+			continue
+		}
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		seen[name] = true
+		result.KeyCount++
+		result.NextContinuationToken = name
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: name})
+		} else {
+			result.Contents = append(result.Contents, objectEntry{
+				Key:          o.key,
+				LastModified: o.modified,
+				ETag:         o.etag(),
+				Size:         len(o.data),
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// listVersions отвечает на ListObjectVersions: все версии, от новых к старым внутри ключа
+func (s *Server) listVersions(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	result := listVersionsResult{Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: 1000}
+
+	seen := make(map[string]bool)
+	for _, current := range s.latest(bucket) {
+		if !strings.HasPrefix(current.key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(current.key[len(prefix):], delimiter); i >= 0 {
+				name := current.key[:len(prefix)+i+len(delimiter)]
+				if !seen[name] {
+					seen[name] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: name})
+				}
+				continue
+			}
+		}
+
+		objects := s.buckets[bucket]
+		for i := len(objects) - 1; i >= 0; i-- {
+			o := objects[i]
+			if o.key != current.key {
+				continue
+			}
+			result.Versions = append(result.Versions, versionEntry{
+				Key:          o.key,
+				VersionID:    o.versionID,
+				IsLatest:     o == current,
+				LastModified: o.modified,
+				ETag:         o.etag(),
+				Size:         len(o.data),
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+type locationConstraint struct {
+	XMLName  xml.Name `xml:"LocationConstraint"`
+	Location string   `xml:",chardata"`
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name  `xml:"CopyObjectResult"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	Contents              []objectEntry  `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type listVersionsResult struct {
+	XMLName        xml.Name       `xml:"ListVersionsResult"`
+	Name           string         `xml:"Name"`
+	Prefix         string         `xml:"Prefix"`
+	Delimiter      string         `xml:"Delimiter,omitempty"`
+	MaxKeys        int            `xml:"MaxKeys"`
+	IsTruncated    bool           `xml:"IsTruncated"`
+	Versions       []versionEntry `xml:"Version"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type objectEntry struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int       `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+}
+
+type versionEntry struct {
+	Key          string    `xml:"Key"`
+	VersionID    string    `xml:"VersionId"`
+	IsLatest     bool      `xml:"IsLatest"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int       `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type errorResponse struct {
+	XMLName    xml.Name `xml:"Error"`
+	Code       string   `xml:"Code"`
+	Message    string   `xml:"Message"`
+	BucketName string   `xml:"BucketName"`
+	Key        string   `xml:"Key"`
+	RequestID  string   `xml:"RequestId"`
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, bucket, key string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	writeXML(w, status, errorResponse{Code: code, Message: code, BucketName: bucket, Key: key, RequestID: "s3test"})
+}
@@ -0,0 +1,183 @@
+// Package layout описывает, как расписания раскладываются по путям в бакетах.
+// Одна и та же раскладка используется и при записи обработанных файлов, и при листинге,
+// поэтому эндпоинты чтения находят ровно те объекты, которые пишет обработчик
+package layout
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Плейсхолдеры шаблона. Каждый занимает целый сегмент пути и встречается ровно один раз,
+// в порядке university → course → type → file
+const (
+	University = "{university}"
+	Course     = "{course}"
+	Type       = "{type}"
+	File       = "{file}"
+)
+
+var placeholders = []string{University, Course, Type, File}
+
+// Layout — раскладка путей, например universities/{university}/courses/{course}/types/{type}/files/{file}
+type Layout struct {
+	pattern  string
+	segments []string
+	index    map[string]int // Плейсхолдер -> номер сегмента
+}
+
+// Parse разбирает шаблон. Для совместимости принимается и старый формат FILE_PATH_PATTERN
+// с четырьмя %s (университет, курс, тип, файл)
+func Parse(pattern string) (*Layout, error) {
+	pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+	if strings.Count(pattern, "%s") == 4 && !strings.Contains(pattern, "{") {
+		pattern = fmt.Sprintf(pattern, University, Course, Type, File)
+	}
+
+	l := &Layout{
+		pattern:  pattern,
+		segments: strings.Split(pattern, "/"),
+		index:    make(map[string]int, len(placeholders)),
+	}
+
+	for i, segment := range l.segments {
+		if segment == "" {
+			return nil, fmt.Errorf("layout %q: empty path segment", pattern)
+		}
+		for _, p := range placeholders {
+			if !strings.Contains(segment, p) {
+				continue
+			}
+			if segment != p {
+				return nil, fmt.Errorf("layout %q: %s must occupy a whole path segment", pattern, p)
+			}
+			if _, dup := l.index[p]; dup {
+				return nil, fmt.Errorf("layout %q: %s is used more than once", pattern, p)
+			}
+			l.index[p] = i
+		}
+	}
+
+	prev := -1
+	for _, p := range placeholders {
+		i, ok := l.index[p]
+		if !ok {
+			return nil, fmt.Errorf("layout %q: missing %s", pattern, p)
+		}
+		if i <= prev {
+			return nil, fmt.Errorf("layout %q: placeholders must appear in order %s", pattern, strings.Join(placeholders, ", "))
+		}
+		prev = i
+	}
+	if l.index[File] != len(l.segments)-1 {
+		return nil, fmt.Errorf("layout %q: %s must be the last segment", pattern, File)
+	}
+
+	return l, nil
+}
+
+func (l *Layout) String() string {
+	return l.pattern
+}
+
+// ObjectPath возвращает путь к файлу расписания
+func (l *Layout) ObjectPath(university, course, scheduleType, fileName string) string {
+	return l.render(len(l.segments), university, course, scheduleType, fileName)
+}
+
+// UniversitiesPrefix — префикс, непосредственно под которым лежат папки университетов
+func (l *Layout) UniversitiesPrefix() string {
+	return l.render(l.index[University])
+}
+
+// CoursesPrefix — префикс, под которым лежат папки курсов университета
+func (l *Layout) CoursesPrefix(university string) string {
+	return l.render(l.index[Course], university)
+}
+
+// TypesPrefix — префикс, под которым лежат папки типов расписаний
+func (l *Layout) TypesPrefix(university, course string) string {
+	return l.render(l.index[Type], university, course)
+}
+
+// FilesPrefix — префикс, под которым лежат файлы расписаний
+func (l *Layout) FilesPrefix(university, course, scheduleType string) string {
+	return l.render(l.index[File], university, course, scheduleType)
+}
+
+// InUniversity сообщает, что объект или префикс objectPath лежит в папке университета university
+func (l *Layout) InUniversity(objectPath, university string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(objectPath, "/"), l.CoursesPrefix(university))
+}
+
+// render подставляет значения в первые n сегментов. Для префиксов (n меньше числа сегментов)
+// результат заканчивается на "/", пустой префикс означает корень бакета
+func (l *Layout) render(n int, values ...string) string {
+	parts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		segment := l.segments[i]
+		for j, p := range placeholders {
+			if segment == p && j < len(values) {
+				segment = values[j]
+			}
+		}
+		parts = append(parts, segment)
+	}
+
+	path := strings.Join(parts, "/")
+	if n < len(l.segments) && path != "" {
+		path += "/"
+	}
+	return path
+}
+
+// Resolver выбирает раскладку для университета: собственную, если она настроена, иначе общую
+type Resolver struct {
+	defaultLayout *Layout
+	universities  map[string]*Layout
+}
+
+// NewResolver создаёт Resolver из общего шаблона и шаблонов по университетам
+func NewResolver(defaultPattern string, perUniversity map[string]string) (*Resolver, error) {
+	defaultLayout, err := Parse(defaultPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Resolver{
+		defaultLayout: defaultLayout,
+		universities:  make(map[string]*Layout, len(perUniversity)),
+	}
+	for university, pattern := range perUniversity {
+		l, err := Parse(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("university %s: %w", university, err)
+		}
+		r.universities[university] = l
+	}
+	return r, nil
+}
+
+// Default возвращает общую раскладку
+func (r *Resolver) Default() *Layout {
+	return r.defaultLayout
+}
+
+// For возвращает раскладку университета
+func (r *Resolver) For(university string) *Layout {
+	if l, ok := r.universities[university]; ok {
+		return l
+	}
+	return r.defaultLayout
+}
+
+// CustomUniversities возвращает университеты с собственной раскладкой в алфавитном порядке
+func (r *Resolver) CustomUniversities() []string {
+	names := make([]string, 0, len(r.universities))
+	for name := range r.universities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
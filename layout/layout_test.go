@@ -0,0 +1,158 @@
+package layout
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string // Нормализованный шаблон
+		wantErr string // Подстрока ошибки; пусто — шаблон корректен
+	}{
+		{name: "placeholders", pattern: "universities/{university}/courses/{course}/types/{type}/files/{file}", want: "universities/{university}/courses/{course}/types/{type}/files/{file}"},
+		{name: "legacy printf pattern", pattern: "universities/%s/courses/%s/types/%s/files/%s", want: "universities/{university}/courses/{course}/types/{type}/files/{file}"},
+		{name: "university first", pattern: "{university}/{course}/{type}/{file}", want: "{university}/{course}/{type}/{file}"},
+		{name: "surrounding slashes and spaces", pattern: " /{university}/{course}/{type}/{file}/ ", want: "{university}/{course}/{type}/{file}"},
+		{name: "extra static segments", pattern: "data/{university}/faculties/it/{course}/{type}/{file}", want: "data/{university}/faculties/it/{course}/{type}/{file}"},
+		{name: "missing placeholder", pattern: "{university}/{course}/{file}", wantErr: "missing {type}"},
+		{name: "wrong order", pattern: "{course}/{university}/{type}/{file}", wantErr: "must appear in order"},
+		{name: "placeholder inside a segment", pattern: "u-{university}/{course}/{type}/{file}", wantErr: "whole path segment"},
+		{name: "duplicate placeholder", pattern: "{university}/{university}/{course}/{type}/{file}", wantErr: "more than once"},
+		{name: "file is not last", pattern: "{university}/{course}/{type}/{file}/raw", wantErr: "last segment"},
+		{name: "empty segment", pattern: "{university}//{course}/{type}/{file}", wantErr: "empty path segment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := Parse(tt.pattern)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want %q", tt.pattern, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.pattern, err)
+			}
+			if l.String() != tt.want {
+				t.Errorf("pattern = %q, want %q", l.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutPaths(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		object       string
+		universities string
+		courses      string
+		types        string
+		files        string
+	}{
+		{
+			pattern:      "universities/{university}/courses/{course}/types/{type}/files/{file}",
+			object:       "universities/kgu/courses/1/types/regular/files/a.json",
+			universities: "universities/",
+			courses:      "universities/kgu/courses/",
+			types:        "universities/kgu/courses/1/types/",
+			files:        "universities/kgu/courses/1/types/regular/files/",
+		},
+		{
+			pattern:      "{university}/{course}/{type}/{file}",
+			object:       "kgu/1/regular/a.json",
+			universities: "",
+			courses:      "kgu/",
+			types:        "kgu/1/",
+			files:        "kgu/1/regular/",
+		},
+		{
+			pattern:      "universities/{university}/faculties/it/courses/{course}/{type}/{file}",
+			object:       "universities/kgu/faculties/it/courses/1/regular/a.json",
+			universities: "universities/",
+			courses:      "universities/kgu/faculties/it/courses/",
+			types:        "universities/kgu/faculties/it/courses/1/",
+			files:        "universities/kgu/faculties/it/courses/1/regular/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			l, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{
+				l.ObjectPath("kgu", "1", "regular", "a.json"),
+				l.UniversitiesPrefix(),
+				l.CoursesPrefix("kgu"),
+				l.TypesPrefix("kgu", "1"),
+				l.FilesPrefix("kgu", "1", "regular"),
+			}
+			want := []string{tt.object, tt.universities, tt.courses, tt.types, tt.files}
+			if !slices.Equal(got, want) {
+				t.Errorf("paths\n got: %q\nwant: %q", got, want)
+			}
+			// Файл лежит под префиксом своих каталогов — на этом держится листинг
+			for _, prefix := range want[1:] {
+				if !strings.HasPrefix(tt.object, prefix) {
+					t.Errorf("object %q is not under prefix %q", tt.object, prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestInUniversity(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		path       string
+		university string
+		want       bool
+	}{
+		{"{university}/{course}/{type}/{file}", "audit/", "audit", true},
+		{"{university}/{course}/{type}/{file}", "webhooks/subscriptions.json", "webhooks", true},
+		{"{university}/{course}/{type}/{file}", "audit/", "auditorium", false},
+		{"{university}/{course}/{type}/{file}", "audit/", "kgu", false},
+		{"universities/{university}/courses/{course}/types/{type}/files/{file}", "audit/", "audit", false},
+		{"universities/{university}/courses/{course}/types/{type}/files/{file}", "universities/kgu/courses/", "kgu", true},
+	}
+
+	for _, tt := range tests {
+		l, err := Parse(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.InUniversity(tt.path, tt.university); got != tt.want {
+			t.Errorf("%s: InUniversity(%q, %q) = %v, want %v", tt.pattern, tt.path, tt.university, got, tt.want)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	r, err := NewResolver("universities/%s/courses/%s/types/%s/files/%s", map[string]string{
+		"kgu":  "{university}/{course}/{type}/{file}",
+		"agtu": "archive/{university}/{course}/{type}/{file}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.For("kgu").ObjectPath("kgu", "1", "regular", "a.json"); got != "kgu/1/regular/a.json" {
+		t.Errorf("custom layout path = %q", got)
+	}
+	if got := r.For("other").ObjectPath("other", "1", "regular", "a.json"); got != "universities/other/courses/1/types/regular/files/a.json" {
+		t.Errorf("default layout path = %q", got)
+	}
+	if got := r.CustomUniversities(); !slices.Equal(got, []string{"agtu", "kgu"}) {
+		t.Errorf("CustomUniversities() = %v", got)
+	}
+
+	if _, err := NewResolver("{university}/{course}/{type}/{file}", map[string]string{"kgu": "{university}/{file}"}); err == nil || !strings.Contains(err.Error(), "university kgu") {
+		t.Errorf("invalid custom layout error = %v", err)
+	}
+}
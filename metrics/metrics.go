@@ -0,0 +1,143 @@
+// Package metrics содержит метрики Prometheus сервиса
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "schedule_api"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	StorageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_duration_seconds",
+		Help:      "MinIO call latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	StorageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_operation_errors_total",
+		Help:      "Failed MinIO calls by operation.",
+	}, []string{"operation"})
+
+	ParseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "parse_duration_seconds",
+		Help:      "XLSX parsing duration by schedule type and outcome.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"schedule_type", "outcome"})
+
+	ProcessingQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "processing_queue_depth",
+		Help:      "Files accepted by /files_uploaded and not processed yet.",
+	})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_attempts_total",
+		Help:      "Outgoing webhook delivery attempts by outcome.",
+	}, []string{"outcome"})
+)
+
+// ObserveStorage учитывает длительность и результат вызова MinIO
+func ObserveStorage(operation string, start time.Time, err error) {
+	StorageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		StorageErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveParse учитывает длительность и результат парсинга
+func ObserveParse(scheduleType string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	ParseDuration.WithLabelValues(scheduleType, outcome).Observe(time.Since(start).Seconds())
+}
+
+// CacheCounters — накопленные счётчики кэша по семействам ключей
+type CacheCounters struct {
+	Hits          map[string]uint64
+	Misses        map[string]uint64
+	Evictions     map[string]uint64
+	Invalidations map[string]uint64
+}
+
+// CacheStatsFunc возвращает накопленные счётчики кэша
+type CacheStatsFunc func() CacheCounters
+
+// RegisterCache регистрирует метрики кэша, значения читаются при каждом сборе
+func RegisterCache(stats CacheStatsFunc) {
+	prometheus.MustRegister(&cacheCollector{stats: stats})
+}
+
+var (
+	cacheHitsDesc = prometheus.NewDesc(namespace+"_cache_hits_total",
+		"Cache hits by key family.", []string{"family"}, nil)
+	cacheMissesDesc = prometheus.NewDesc(namespace+"_cache_misses_total",
+		"Cache misses by key family.", []string{"family"}, nil)
+	cacheEvictionsDesc = prometheus.NewDesc(namespace+"_cache_evictions_total",
+		"Cache entries expired by key family.", []string{"family"}, nil)
+	cacheInvalidationsDesc = prometheus.NewDesc(namespace+"_cache_invalidations_total",
+		"Cache entries deleted after schedule updates or flushed by key family.", []string{"family"}, nil)
+	cacheHitRatioDesc = prometheus.NewDesc(namespace+"_cache_hit_ratio",
+		"Share of cache lookups that were hits since start.", nil, nil)
+)
+
+type cacheCollector struct {
+	stats CacheStatsFunc
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheInvalidationsDesc
+	ch <- cacheHitRatioDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	counters := c.stats()
+
+	var totalHits, total uint64
+	for family, n := range counters.Hits {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(n), family)
+		totalHits += n
+		total += n
+	}
+	for family, n := range counters.Misses {
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(n), family)
+		total += n
+	}
+	for family, n := range counters.Evictions {
+		ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(n), family)
+	}
+	for family, n := range counters.Invalidations {
+		ch <- prometheus.MustNewConstMetric(cacheInvalidationsDesc, prometheus.CounterValue, float64(n), family)
+	}
+
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(totalHits) / float64(total)
+	}
+	ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, ratio)
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"strings"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// Authenticate определяет клиента по заголовку X-API-Key (или Authorization: ApiKey <key>)
+// либо по JWT из Authorization: Bearer <token>.
+// Запросы без учётных данных проходят анонимно, запросы с неверными данными отклоняются.
+// При выключенной аутентификации каждый запрос получает роль admin (для локальной разработки).
+// Каждая неудачная попытка списывает токен из bucket'а IP клиента в failures: пока он пуст,
+// учётные данные с этого адреса не проверяются и запрос получает 429
+func Authenticate(auth *services.AuthService, enabled bool, failures *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Set(principalKey, &models.Principal{
+				Subject:    "anonymous",
+				Role:       models.RoleAdmin,
+				AuthMethod: "disabled",
+			})
+			c.Next()
+			return
+		}
+
+		token, rawKey := bearerTokenFromRequest(c), apiKeyFromRequest(c)
+		if token == "" && rawKey == "" {
+			c.Next()
+			return
+		}
+
+		ipKey := clientIPKey(c)
+		if exhausted, retryAfter := failures.Exhausted(ipKey); exhausted {
+			abortRateLimited(c, failures, retryAfter)
+			return
+		}
+
+		if token != "" {
+			principal, err := auth.AuthenticateToken(c.Request.Context(), token)
+			if err != nil {
+				failures.Allow(ipKey)
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				logging.FromContext(c.Request.Context()).Debug("bearer token rejected", "error", err)
+				abortWithError(c, models.ErrCodeInvalidToken)
+				return
+			}
+			c.Set(principalKey, principal)
+			c.Next()
+			return
+		}
+
+		principal, ok := auth.Authenticate(rawKey)
+		if !ok {
+			failures.Allow(ipKey)
+			abortWithError(c, models.ErrCodeInvalidAPIKey)
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireRole пропускает только клиентов с ролью не ниже указанной.
+// Если в маршруте есть параметр :university, дополнительно проверяется область доступа клиента.
+func RequireRole(role models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := CurrentPrincipal(c)
+		if principal == nil {
+			abortWithError(c, models.ErrCodeAuthenticationRequired)
+			return
+		}
+
+		if !principal.Role.Includes(role) {
+			abortWithError(c, models.ErrCodeInsufficientRole, role)
+			return
+		}
+
+		if university := c.Param("university"); university != "" && !principal.CanAccessUniversity(university) {
+			abortWithError(c, models.ErrCodeUniversityAccessDenied, university)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAllUniversities пропускает только клиентов без ограничения по университетам.
+// Ставится после RequireRole на действия, затрагивающие все университеты сразу
+// (сброс кэша, справочник преподавателей, журнал доставок webhook'ов)
+func RequireAllUniversities() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := CurrentPrincipal(c)
+		if principal == nil {
+			abortWithError(c, models.ErrCodeAuthenticationRequired)
+			return
+		}
+		if !principal.AllUniversities() {
+			abortWithError(c, models.ErrCodeAllUniversitiesRequired)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentPrincipal возвращает клиента, аутентифицированного для запроса, или nil
+func CurrentPrincipal(c *gin.Context) *models.Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*models.Principal)
+	return principal
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, value, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "ApiKey") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func bearerTokenFromRequest(c *gin.Context) string {
+	scheme, value, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
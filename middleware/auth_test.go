@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoleIncludes(t *testing.T) {
+	roles := []models.Role{models.RoleReader, models.RoleUploader, models.RoleAdmin}
+	for i, role := range roles {
+		for j, other := range roles {
+			if got, want := role.Includes(other), i >= j; got != want {
+				t.Errorf("%s.Includes(%s) = %v, want %v", role, other, got, want)
+			}
+		}
+	}
+	if models.Role("owner").Includes(models.RoleReader) || models.Role("").Includes(models.Role("")) {
+		t.Error("unknown role includes other roles")
+	}
+}
+
+// authTestRouter проверяет ключи API с ограничением попыток, выключенным для тестов
+func authTestRouter(enabled bool, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	auth := services.NewAuthService([]models.APIKey{
+		{Name: "reader", Hash: services.HashAPIKey("reader-key"), Role: models.RoleReader},
+		{Name: "uploader", Hash: services.HashAPIKey("uploader-key"), Role: models.RoleUploader},
+		{Name: "kgu-admin", Hash: services.HashAPIKey("kgu-admin-key"), Role: models.RoleAdmin, Universities: []string{"kgu"}},
+		{Name: "admin", Hash: services.HashAPIKey("admin-key"), Role: models.RoleAdmin, Universities: []string{"*"}},
+	}, nil)
+
+	router := gin.New()
+	router.Use(Authenticate(auth, enabled, NewRateLimiter("authentication", 0, 0)))
+	handlers = append(handlers, func(c *gin.Context) {
+		subject := "anonymous"
+		if principal := CurrentPrincipal(c); principal != nil {
+			subject = principal.Subject
+		}
+		c.String(http.StatusOK, subject)
+	})
+	router.GET("/universities/:university", handlers...)
+	router.GET("/global", handlers...)
+	return router
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		chain    []gin.HandlerFunc
+		path     string
+		key      string
+		wantCode int
+		wantErr  models.ErrorCode
+	}{
+		{name: "anonymous read", enabled: true, path: "/global", wantCode: http.StatusOK},
+		{name: "invalid key", enabled: true, path: "/global", key: "guess", wantCode: http.StatusUnauthorized, wantErr: models.ErrCodeInvalidAPIKey},
+		{name: "no credentials", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/global", wantCode: http.StatusUnauthorized, wantErr: models.ErrCodeAuthenticationRequired},
+		{name: "lower role", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/global", key: "reader-key", wantCode: http.StatusForbidden, wantErr: models.ErrCodeInsufficientRole},
+		{name: "same role", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/global", key: "uploader-key", wantCode: http.StatusOK},
+		{name: "higher role", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/global", key: "admin-key", wantCode: http.StatusOK},
+		{name: "university in scope", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/universities/kgu", key: "kgu-admin-key", wantCode: http.StatusOK},
+		{name: "university out of scope", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleUploader)}, path: "/universities/agtu", key: "kgu-admin-key", wantCode: http.StatusForbidden, wantErr: models.ErrCodeUniversityAccessDenied},
+		{name: "scoped admin on a global action", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleAdmin), RequireAllUniversities()}, path: "/global", key: "kgu-admin-key", wantCode: http.StatusForbidden, wantErr: models.ErrCodeAllUniversitiesRequired},
+		{name: "wildcard admin on a global action", enabled: true, chain: []gin.HandlerFunc{RequireRole(models.RoleAdmin), RequireAllUniversities()}, path: "/global", key: "admin-key", wantCode: http.StatusOK},
+		{name: "authentication disabled", enabled: false, chain: []gin.HandlerFunc{RequireRole(models.RoleAdmin), RequireAllUniversities()}, path: "/global", key: "guess", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := authTestRouter(tt.enabled, tt.chain...)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "ApiKey "+tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantErr == "" {
+				return
+			}
+			var body models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != tt.wantErr {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHeaders(t *testing.T) {
+	router := authTestRouter(true)
+
+	for name, header := range map[string][2]string{
+		"X-API-Key":             {"X-API-Key", " uploader-key "},
+		"Authorization: ApiKey": {"Authorization", "apikey uploader-key"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/global", nil)
+		req.Header.Set(header[0], header[1])
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "uploader" {
+			t.Errorf("%s: %d %q, want 200 uploader", name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,21 +1,128 @@
 package middleware
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
+const corsAllowHeaders = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-Request-ID, accept, origin, Cache-Control, X-Requested-With"
+
+// CORSOptions — политика CORS.
+// AllowedOrigins поддерживает точные значения ("https://schedule.example.edu"),
+// поддомены по маске ("https://*.example.edu" — с любым портом, "https://*.example.edu:8443" — только с этим)
+// и "*" для любого источника
+type CORSOptions struct {
+	AllowedOrigins   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORSPolicy хранит текущую политику CORS и позволяет заменить её без перезапуска
+type CORSPolicy struct {
+	opts atomic.Pointer[CORSOptions]
+}
+
+func NewCORSPolicy(opts CORSOptions) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.Update(opts)
+	return p
+}
+
+// Update применяет новую политику к последующим запросам
+func (p *CORSPolicy) Update(opts CORSOptions) {
+	p.opts.Store(&opts)
+}
+
+// AllowsOrigin сообщает, разрешён ли источник текущей политикой (нужно, например, для WebSocket)
+func (p *CORSPolicy) AllowsOrigin(origin string) bool {
+	return originAllowed(p.opts.Load().AllowedOrigins, origin)
+}
+
+func CORS(policy *CORSPolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		opts := policy.opts.Load()
+		exposed := strings.Join(opts.ExposedHeaders, ", ")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		// Ответ зависит от Origin, поэтому кэши должны учитывать его
+		c.Writer.Header().Add("Vary", "Origin")
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if origin != "" {
+			if !originAllowed(opts.AllowedOrigins, origin) {
+				if preflight {
+					c.AbortWithStatus(http.StatusForbidden)
+					return
+				}
+				c.Next()
+				return
+			}
+
+			// Браузеры отвергают "*" вместе с credentials, поэтому всегда возвращаем конкретный источник
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			if opts.AllowCredentials {
+				c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposed != "" {
+				c.Writer.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			if origin != "" {
+				c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+				c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+				c.Writer.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+				if opts.MaxAge > 0 {
+					c.Writer.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+				}
+			}
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// originAllowed проверяет источник по списку разрешённых, включая маски поддоменов
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == origin || subdomainAllowed(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// subdomainAllowed сравнивает источник с маской поддоменов по схеме и имени хоста.
+// https://*.example.edu разрешает https://a.example.edu, https://a.b.example.edu и https://a.example.edu:8443,
+// но не https://example.edu; маска с портом (https://*.example.edu:8443) разрешает только этот порт
+func subdomainAllowed(pattern, origin string) bool {
+	scheme, domain, found := strings.Cut(pattern, "://*.")
+	if !found {
+		return false
+	}
+	domain, port, withPort := strings.Cut(domain, ":")
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != scheme || u.User != nil || u.Path != "" || u.RawQuery != "" {
+		return false
+	}
+	if withPort && u.Port() != port {
+		return false
+	}
+	host := u.Hostname()
+	return strings.HasSuffix(host, "."+domain) && len(host) > len(domain)+1
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://schedule.example.edu", "https://*.example.com", "http://*.dev.local:3000"}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://schedule.example.edu", true},
+		{"HTTPS://Schedule.Example.edu", true},
+		{"http://schedule.example.edu", false},
+		{"https://schedule.example.edu:8443", false},
+		{"https://other.example.edu", false},
+
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://app.example.com:8443", true},
+		{"https://example.com", false},
+		{"https://example.com:8443", false},
+		{"http://app.example.com", false},
+		{"https://app.example.com.evil.org", false},
+		{"https://appexample.com", false},
+		{"https://evil.org/.example.com", false},
+		{"https://user@app.example.com", false},
+
+		{"http://ui.dev.local:3000", true},
+		{"http://ui.dev.local", false},
+		{"http://ui.dev.local:3001", false},
+
+		{"", false},
+		{"null", false},
+	}
+
+	for _, tt := range tests {
+		if got := originAllowed(allowed, tt.origin); got != tt.want {
+			t.Errorf("originAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	if !originAllowed([]string{"*"}, "https://anything.example.org") {
+		t.Error(`"*" does not allow an arbitrary origin`)
+	}
+}
+
+func corsTestRouter(opts CORSOptions) (*gin.Engine, *CORSPolicy) {
+	gin.SetMode(gin.TestMode)
+	policy := NewCORSPolicy(opts)
+	router := gin.New()
+	router.Use(CORS(policy))
+	router.GET("/schedules", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router, policy
+}
+
+func TestCORS(t *testing.T) {
+	router, _ := corsTestRouter(CORSOptions{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		ExposedHeaders:   []string{"X-Request-ID", "Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	preflightHeaders := map[string]string{"Access-Control-Request-Method": "GET"}
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		headers     map[string]string
+		wantStatus  int
+		wantOrigin  string
+		wantHeaders map[string]string
+		wantVary    []string
+	}{
+		{
+			name:       "allowed origin",
+			method:     http.MethodGet,
+			origin:     "https://app.example.com:8443",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com:8443",
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID, Retry-After",
+				"Access-Control-Allow-Methods":     "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:       "request without origin",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantVary:   []string{"Origin"},
+		},
+		{
+			name:       "other origin is served without cors headers",
+			method:     http.MethodGet,
+			origin:     "https://evil.org",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Expose-Headers":    "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:       "preflight",
+			method:     http.MethodOptions,
+			origin:     "https://app.example.com",
+			headers:    preflightHeaders,
+			wantStatus: http.StatusNoContent,
+			wantOrigin: "https://app.example.com",
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods":     "POST, OPTIONS, GET, PUT, DELETE",
+				"Access-Control-Allow-Headers":     corsAllowHeaders,
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Allow-Credentials": "true",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:       "preflight from other origin",
+			method:     http.MethodOptions,
+			origin:     "https://example.com",
+			headers:    preflightHeaders,
+			wantStatus: http.StatusForbidden,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+			},
+			wantVary: []string{"Origin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/schedules", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			for key, want := range tt.wantHeaders {
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			if vary := w.Header().Values("Vary"); !slices.Equal(vary, tt.wantVary) {
+				t.Errorf("Vary = %v, want %v", vary, tt.wantVary)
+			}
+		})
+	}
+}
+
+func TestCORSPolicyUpdate(t *testing.T) {
+	router, policy := corsTestRouter(CORSOptions{AllowedOrigins: []string{"https://old.example.edu"}})
+	allowOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/schedules", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin("https://old.example.edu"); got != "https://old.example.edu" {
+		t.Fatalf("before update: Access-Control-Allow-Origin = %q", got)
+	}
+
+	policy.Update(CORSOptions{AllowedOrigins: []string{"https://new.example.edu"}})
+	if got := allowOrigin("https://old.example.edu"); got != "" {
+		t.Errorf("removed origin is still allowed: %q", got)
+	}
+	if got := allowOrigin("https://new.example.edu"); got != "https://new.example.edu" {
+		t.Errorf("added origin: Access-Control-Allow-Origin = %q", got)
+	}
+	if !policy.AllowsOrigin("https://new.example.edu") || policy.AllowsOrigin("https://old.example.edu") {
+		t.Error("AllowsOrigin does not follow the updated policy")
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// abortWithError прерывает обработку запроса ошибкой с кодом из каталога
+func abortWithError(c *gin.Context, code models.ErrorCode, args ...any) {
+	c.AbortWithStatusJSON(code.Status(), NewErrorResponse(c, code, args...))
+}
+
+// NewErrorResponse формирует ответ с ошибкой на языке клиента и идентификатором запроса
+func NewErrorResponse(c *gin.Context, code models.ErrorCode, args ...any) models.ErrorResponse {
+	lang := Language(c)
+	c.Header("Content-Language", lang)
+	return models.ErrorResponse{
+		Code:      code,
+		Error:     code.Message(lang, args...),
+		RequestID: logging.RequestID(c.Request.Context()),
+	}
+}
+
+// Language выбирает язык сообщений по заголовку Accept-Language: ru или en.
+// Берётся поддерживаемый язык с наибольшим весом q, без заголовка — английский
+func Language(c *gin.Context) string {
+	lang, weight := models.LanguageEnglish, 0.0
+	for _, part := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary != models.LanguageEnglish && primary != models.LanguageRussian {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > weight {
+			lang, weight = primary, q
+		}
+	}
+	return lang
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"schedule-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", models.LanguageEnglish},
+		{"ru", models.LanguageRussian},
+		{"ru-RU,ru;q=0.9", models.LanguageRussian},
+		{"EN-us", models.LanguageEnglish},
+		{"ru;q=0.5,en;q=0.9", models.LanguageEnglish},
+		{"en;q=0.3, ru;q=0.7", models.LanguageRussian},
+		{"de,ru;q=0.2", models.LanguageRussian},
+		{"de,fr;q=0.8", models.LanguageEnglish},
+		{"ru;q=abc,en;q=0.1", models.LanguageEnglish},
+		{"ru;q=0", models.LanguageEnglish},
+		{"*", models.LanguageEnglish},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			c.Request.Header.Set("Accept-Language", tt.header)
+		}
+		if got := Language(c); got != tt.want {
+			t.Errorf("Language(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNewErrorResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("Accept-Language", "en;q=0.4,ru-RU;q=0.8")
+
+	response := NewErrorResponse(c, models.ErrCodeGroupNotFound, "23101")
+	if response.Code != models.ErrCodeGroupNotFound || response.Error != "группа 23101 не найдена в расписании" {
+		t.Errorf("response = %+v", response)
+	}
+	if lang := w.Header().Get("Content-Language"); lang != models.LanguageRussian {
+		t.Errorf("Content-Language = %q, want ru", lang)
+	}
+}
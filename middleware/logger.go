@@ -1,12 +1,20 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
+	"schedule-api/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
+var probePaths = map[string]bool{
+	"/healthz":       true,
+	"/readyz":        true,
+	"/api/v1/health": true,
+}
+
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -15,22 +23,36 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
 		statusCode := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case probePaths[path] && statusCode < 400:
+			// Пробы Kubernetes приходят каждые несколько секунд и засоряют лог
+			level = slog.LevelDebug
+		case statusCode >= 500:
+			level = slog.LevelError
+		case statusCode >= 400:
+			level = slog.LevelWarn
+		}
 
+		attrs := []slog.Attr{
+			slog.Int("status", statusCode),
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
 		if raw != "" {
-			path = path + "?" + raw
+			attrs = append(attrs, slog.String("query", raw))
+		}
+		if principal := CurrentPrincipal(c); principal != nil {
+			attrs = append(attrs, slog.String("actor", principal.Subject))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
 		}
 
-		log.Printf("[GIN] %v | %3d | %13v | %15s | %-7s %s",
-			start.Format("2006/01/02 - 15:04:05"),
-			statusCode,
-			latency,
-			clientIP,
-			method,
-			path,
-		)
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "http request", attrs...)
 	}
 }
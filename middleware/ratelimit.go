@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"schedule-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const rateLimitIdleTTL = 10 * time.Minute
+
+// RateLimiter — набор token bucket'ов, по одному на клиента
+type RateLimiter struct {
+	name  string
+	rate  float64 // Токенов в секунду
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewRateLimiter создаёт ограничитель на perMinute запросов в минуту с допустимым всплеском burst.
+// perMinute <= 0 отключает ограничение
+func NewRateLimiter(name string, perMinute, burst int) *RateLimiter {
+	l := &RateLimiter{
+		name:      name,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+	l.SetLimit(perMinute, burst)
+	return l
+}
+
+// SetLimit меняет лимиты на лету; накопленные клиентами токены обрезаются до нового burst
+func (l *RateLimiter) SetLimit(perMinute, burst int) {
+	if burst <= 0 {
+		burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = float64(perMinute) / 60
+	l.burst = float64(burst)
+	for _, b := range l.buckets {
+		b.tokens = math.Min(b.tokens, l.burst)
+	}
+}
+
+// Allow списывает токен клиента. Если токенов нет, возвращает время до появления следующего.
+// При выключенном ограничении limit равен 0
+func (l *RateLimiter) Allow(key string) (allowed bool, limit, remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true, 0, 0, 0
+	}
+	limit = int(l.burst)
+
+	b := l.refill(key)
+	if b.tokens < 1 {
+		return false, limit, 0, l.wait(b)
+	}
+
+	b.tokens--
+	return true, limit, int(b.tokens), 0
+}
+
+// Exhausted сообщает, что у клиента не осталось токенов, не списывая их.
+// Используется, когда токен списывается только за неудачную попытку
+func (l *RateLimiter) Exhausted(key string) (exhausted bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return false, 0
+	}
+	if b := l.refill(key); b.tokens < 1 {
+		return true, l.wait(b)
+	}
+	return false, 0
+}
+
+// refill возвращает bucket клиента с токенами, накопленными с прошлого запроса
+func (l *RateLimiter) refill(key string) *tokenBucket {
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+	return b
+}
+
+// wait — время до появления в bucket'е целого токена
+func (l *RateLimiter) wait(b *tokenBucket) time.Duration {
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep удаляет bucket'ы давно не появлявшихся клиентов
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > rateLimitIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit ограничивает частоту запросов по API-ключу/субъекту токена, а для анонимных клиентов — по IP
+func RateLimit(l *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := clientIPKey(c)
+		if principal := CurrentPrincipal(c); principal != nil && principal.AuthMethod != "disabled" {
+			key = principal.AuthMethod + ":" + principal.Subject
+		}
+
+		allowed, limit, remaining, retryAfter := l.Allow(key)
+		if limit == 0 {
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			abortRateLimited(c, l, retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientIPKey — ключ bucket'а клиента по IP. Адрес из X-Forwarded-For учитывается
+// только от доверенных прокси (TRUSTED_PROXIES), иначе берётся адрес соединения
+func clientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// abortRateLimited отвечает 429 с заголовком Retry-After в целых секундах
+func abortRateLimited(c *gin.Context, l *RateLimiter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	abortWithError(c, models.ErrCodeRateLimitExceeded, l.name, seconds)
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"schedule-api/models"
+	"schedule-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestLimiter создаёт ограничитель с управляемыми часами
+func newTestLimiter(perMinute, burst int) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)
+	l := NewRateLimiter("read", perMinute, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l, now := newTestLimiter(60, 2) // Токен в секунду
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _, _ := l.Allow("a"); !allowed {
+			t.Fatalf("request %d within burst was rejected", i+1)
+		}
+	}
+	allowed, limit, remaining, retryAfter := l.Allow("a")
+	if allowed || limit != 2 || remaining != 0 || retryAfter != time.Second {
+		t.Fatalf("over burst: allowed=%v limit=%d remaining=%d retryAfter=%v", allowed, limit, remaining, retryAfter)
+	}
+	if allowed, _, _, _ := l.Allow("b"); !allowed {
+		t.Error("another client shares the exhausted bucket")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if allowed, _, _, retryAfter := l.Allow("a"); allowed || retryAfter != 500*time.Millisecond {
+		t.Errorf("half a token: allowed=%v retryAfter=%v", allowed, retryAfter)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if allowed, _, _, _ := l.Allow("a"); !allowed {
+		t.Error("request after refill was rejected")
+	}
+
+	// Простой не накапливает токенов больше burst
+	*now = now.Add(time.Hour)
+	if _, _, remaining, _ := l.Allow("a"); remaining != 1 {
+		t.Errorf("remaining after idle = %d, want 1", remaining)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l, _ := newTestLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if allowed, limit, _, _ := l.Allow("a"); !allowed || limit != 0 {
+			t.Fatalf("disabled limiter: allowed=%v limit=%d", allowed, limit)
+		}
+	}
+	if exhausted, _ := l.Exhausted("a"); exhausted {
+		t.Error("disabled limiter reports an exhausted bucket")
+	}
+}
+
+// newRateLimitedRouter собирает маршрут с ограничением частоты без доверенных прокси.
+// principal, если задан, подставляется вместо аутентификации
+func newRateLimitedRouter(t *testing.T, l *RateLimiter, principal *models.Principal) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	if principal != nil {
+		router.Use(func(c *gin.Context) { c.Set(principalKey, principal) })
+	}
+	router.GET("/", RateLimit(l), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func serve(router *gin.Engine, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	l, _ := newTestLimiter(30, 1) // Токен раз в 2 секунды
+	router := newRateLimitedRouter(t, l, nil)
+
+	w := serve(router, "192.0.2.1:1000", nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("first request: %d, limit %q, remaining %q", w.Code, w.Header().Get("X-RateLimit-Limit"), w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w = serve(router, "192.0.2.1:1000", map[string]string{"Accept-Language": "ru"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	var body models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != models.ErrCodeRateLimitExceeded {
+		t.Errorf("body = %s, want %s", w.Body.String(), models.ErrCodeRateLimitExceeded)
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		first     string // RemoteAddr первого запроса
+		second    string // RemoteAddr второго запроса
+		headers   map[string]string
+		wantCode  int // Статус второго запроса при burst 1
+	}{
+		{
+			name:     "anonymous clients are keyed by address",
+			first:    "192.0.2.1:1000",
+			second:   "192.0.2.2:1000",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "port is not part of the key",
+			first:    "192.0.2.1:1000",
+			second:   "192.0.2.1:2000",
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "X-Forwarded-For from an untrusted client is ignored",
+			first:    "192.0.2.1:1000",
+			second:   "192.0.2.1:1000",
+			headers:  map[string]string{"X-Forwarded-For": "198.51.100.7"},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:      "API key is keyed by key name across addresses",
+			principal: &models.Principal{Subject: "faculty", Role: models.RoleUploader, AuthMethod: "api_key"},
+			first:     "192.0.2.1:1000",
+			second:    "192.0.2.2:1000",
+			wantCode:  http.StatusTooManyRequests,
+		},
+		{
+			name:      "disabled authentication falls back to address",
+			principal: &models.Principal{Subject: "anonymous", Role: models.RoleAdmin, AuthMethod: "disabled"},
+			first:     "192.0.2.1:1000",
+			second:    "192.0.2.2:1000",
+			wantCode:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(1, 1)
+			router := newRateLimitedRouter(t, l, tt.principal)
+
+			if w := serve(router, tt.first, nil); w.Code != http.StatusOK {
+				t.Fatalf("first request: %d", w.Code)
+			}
+			if w := serve(router, tt.second, tt.headers); w.Code != tt.wantCode {
+				t.Errorf("second request: %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLimitsFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auth := services.NewAuthService([]models.APIKey{
+		{Name: "faculty", Hash: services.HashAPIKey("valid-key"), Role: models.RoleUploader},
+	}, nil)
+	failures, _ := newTestLimiter(1, 2)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(Authenticate(auth, true, failures))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	steps := []struct {
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{"192.0.2.1:1000", map[string]string{"X-API-Key": "guess-1"}, http.StatusUnauthorized},
+		{"192.0.2.1:1000", map[string]string{"Authorization": "Bearer forged"}, http.StatusUnauthorized},
+		// Попытки исчерпаны: даже верный ключ с этого адреса не проверяется
+		{"192.0.2.1:1000", map[string]string{"X-API-Key": "valid-key"}, http.StatusTooManyRequests},
+		// Запросы без учётных данных не ограничиваются, другие адреса — тоже
+		{"192.0.2.1:1000", nil, http.StatusOK},
+		{"192.0.2.2:1000", map[string]string{"X-API-Key": "valid-key"}, http.StatusOK},
+	}
+
+	for i, step := range steps {
+		w := serve(router, step.remoteAddr, step.headers)
+		if w.Code != step.want {
+			t.Fatalf("step %d: %d, want %d (%s)", i+1, w.Code, step.want, w.Body.String())
+		}
+		if step.want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("step %d: missing Retry-After", i+1)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery перехватывает panic в обработчиках, пишет её в лог со стеком и отвечает 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).Error("panic recovered",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()),
+		)
+		abortWithError(c, models.ErrCodeInternal)
+	})
+}
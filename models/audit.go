@@ -0,0 +1,69 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	AuditActionProcessFile     = "files.process"
+	AuditActionCacheInvalidate = "cache.invalidate"
+	AuditActionRestoreVersion  = "files.restore_version"
+	AuditActionWebhookCreate   = "webhooks.create"
+	AuditActionWebhookDelete   = "webhooks.delete"
+	AuditActionTeacherMapping  = "teachers.mapping"
+
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+	AuditResultDenied  = "denied"
+)
+
+// AuditEntry — запись журнала административных действий
+type AuditEntry struct {
+	ID           string    `json:"id"`
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor"`
+	AuthMethod   string    `json:"authMethod,omitempty"`
+	ClientIP     string    `json:"clientIp,omitempty"`
+	Action       string    `json:"action"`
+	University   string    `json:"university,omitempty"`
+	Course       string    `json:"course,omitempty"`
+	ScheduleType string    `json:"scheduleType,omitempty"`
+	File         string    `json:"file,omitempty"`
+	SourcePath   string    `json:"sourcePath,omitempty"`
+	SourceETag   string    `json:"sourceEtag,omitempty"`
+	Version      string    `json:"version,omitempty"` // Версия, из которой восстановлен файл
+	TargetPath   string    `json:"targetPath,omitempty"`
+	ReplacedETag string    `json:"replacedEtag,omitempty"` // ETag целевого объекта до перезаписи
+	Webhook      string    `json:"webhook,omitempty"`      // Идентификатор подписки webhook'а
+	Result       string    `json:"result"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// AuditFilter — параметры выборки из журнала
+type AuditFilter struct {
+	From         time.Time
+	To           time.Time
+	Actor        string
+	Action       string
+	University   string
+	Course       string
+	ScheduleType string
+	Result       string
+	Limit        int
+
+	// Область клиента, читающего журнал: пусто — все университеты. Иначе записи
+	// без университета (сброс кэша, webhook'и без области) не попадают в выборку
+	Universities []string
+}
+
+// Matches проверяет запись на соответствие фильтру (без учёта интервала дат)
+func (f AuditFilter) Matches(e AuditEntry) bool {
+	return (f.Actor == "" || f.Actor == e.Actor) &&
+		(f.Action == "" || f.Action == e.Action) &&
+		(f.University == "" || f.University == e.University) &&
+		(f.Course == "" || f.Course == e.Course) &&
+		(f.ScheduleType == "" || f.ScheduleType == e.ScheduleType) &&
+		(f.Result == "" || f.Result == e.Result) &&
+		(len(f.Universities) == 0 || slices.Contains(f.Universities, e.University))
+}
@@ -0,0 +1,58 @@
+package models
+
+import "slices"
+
+// Role — роль клиента API. Роли упорядочены: admin включает права uploader, uploader — права reader
+type Role string
+
+const (
+	RoleReader   Role = "reader"
+	RoleUploader Role = "uploader"
+	RoleAdmin    Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleReader:   1,
+	RoleUploader: 2,
+	RoleAdmin:    3,
+}
+
+// Valid сообщает, известна ли роль
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes сообщает, покрывает ли роль r права роли other
+func (r Role) Includes(other Role) bool {
+	return roleLevels[r] >= roleLevels[other] && r.Valid()
+}
+
+// APIKey — запись о ключе API. Сам ключ не хранится, только его SHA-256 в hex
+type APIKey struct {
+	Name         string   `json:"name"`
+	Hash         string   `json:"hash"`
+	Role         Role     `json:"role"`
+	Universities []string `json:"universities,omitempty"` // Пустой список — доступ ко всем университетам
+}
+
+// Principal — аутентифицированный клиент запроса
+type Principal struct {
+	Subject      string   `json:"subject"`
+	Role         Role     `json:"role"`
+	Universities []string `json:"universities,omitempty"`
+	AuthMethod   string   `json:"authMethod"`
+}
+
+// CanAccessUniversity проверяет, входит ли университет в область действия клиента
+func (p *Principal) CanAccessUniversity(university string) bool {
+	if p == nil {
+		return false
+	}
+	return p.AllUniversities() || slices.Contains(p.Universities, university)
+}
+
+// AllUniversities сообщает, что область действия клиента не ограничена отдельными университетами
+func (p *Principal) AllUniversities() bool {
+	return p != nil && (len(p.Universities) == 0 || slices.Contains(p.Universities, "*"))
+}
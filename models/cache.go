@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// CacheStats — сводная статистика кэша
+type CacheStats struct {
+	ItemCount     int                         `json:"itemCount"`
+	ApproxBytes   int64                       `json:"approxBytes"`
+	Hits          uint64                      `json:"hits"`
+	Misses        uint64                      `json:"misses"`
+	Evictions     uint64                      `json:"evictions"`
+	Invalidations uint64                      `json:"invalidations"`
+	HitRatio      float64                     `json:"hitRatio"`
+	Families      map[string]CacheFamilyStats `json:"families"`
+}
+
+// CacheFamilyStats — статистика по семейству ключей (universities, courses:, types:, files:)
+type CacheFamilyStats struct {
+	Items         int    `json:"items"`
+	ApproxBytes   int64  `json:"approxBytes"`
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`     // Истечение срока
+	Invalidations uint64 `json:"invalidations"` // Удаление ключей после обновления расписаний и полный сброс кэша
+}
+
+type CacheKeyInfo struct {
+	Key         string     `json:"key"`
+	Family      string     `json:"family"`
+	ApproxBytes int64      `json:"approxBytes"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
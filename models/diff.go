@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Виды изменений занятия
+const (
+	LessonAdded    = "added"
+	LessonRemoved  = "removed"
+	LessonModified = "modified"
+)
+
+// ScheduleDiff — смысловая разница между двумя версиями основного расписания
+type ScheduleDiff struct {
+	University   string         `json:"university,omitempty"`
+	Course       string         `json:"course,omitempty"`
+	ScheduleType string         `json:"scheduleType,omitempty"`
+	File         string         `json:"file,omitempty"`
+	From         string         `json:"from"` // Версия до изменений, пусто для первой загрузки
+	To           string         `json:"to"`
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	Summary      DiffSummary    `json:"summary"`
+	Changes      []LessonChange `json:"changes"`
+}
+
+type DiffSummary struct {
+	Added    int      `json:"added"`
+	Removed  int      `json:"removed"`
+	Modified int      `json:"modified"`
+	Groups   []string `json:"groups"` // Группы, у которых есть изменения
+}
+
+// Empty сообщает, что версии не отличаются
+func (s DiffSummary) Empty() bool {
+	return s.Added == 0 && s.Removed == 0 && s.Modified == 0
+}
+
+// Teachers возвращает преподавателей, чьи занятия добавлены, удалены или изменены
+func (d *ScheduleDiff) Teachers() []string {
+	teachers := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, change := range d.Changes {
+		for _, lesson := range []*Lesson{change.Before, change.After} {
+			if lesson == nil {
+				continue
+			}
+			for _, teacher := range lesson.AllTeachers() {
+				if !seen[teacher] {
+					seen[teacher] = true
+					teachers = append(teachers, teacher)
+				}
+			}
+		}
+	}
+	return teachers
+}
+
+// LessonChange — изменение одного занятия группы в конкретный день и время
+type LessonChange struct {
+	Kind      string        `json:"kind"`
+	Group     string        `json:"group"`
+	Date      string        `json:"date,omitempty"`
+	DayOfWeek string        `json:"dayOfWeek,omitempty"`
+	Time      string        `json:"time"`
+	SubGroup  string        `json:"subGroup,omitempty"`
+	Before    *Lesson       `json:"before,omitempty"`
+	After     *Lesson       `json:"after,omitempty"`
+	Fields    []FieldChange `json:"fields,omitempty"` // Только для modified
+}
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
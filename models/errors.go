@@ -0,0 +1,143 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrorCode — стабильный машиночитаемый код ошибки API
+type ErrorCode string
+
+// Запрос клиента
+const (
+	ErrCodeMissingParameter   ErrorCode = "MISSING_PARAMETER"
+	ErrCodeInvalidParameter   ErrorCode = "INVALID_PARAMETER"
+	ErrCodeInvalidLimit       ErrorCode = "INVALID_LIMIT"
+	ErrCodeInvalidPeriod      ErrorCode = "INVALID_PERIOD"
+	ErrCodeInvalidSort        ErrorCode = "INVALID_SORT"
+	ErrCodeInvalidCursor      ErrorCode = "INVALID_CURSOR"
+	ErrCodeInvalidRequestBody ErrorCode = "INVALID_REQUEST_BODY"
+	ErrCodeRateLimitExceeded  ErrorCode = "RATE_LIMIT_EXCEEDED"
+)
+
+// Аутентификация и доступ
+const (
+	ErrCodeAuthenticationRequired  ErrorCode = "AUTHENTICATION_REQUIRED"
+	ErrCodeInvalidAPIKey           ErrorCode = "INVALID_API_KEY"
+	ErrCodeInvalidToken            ErrorCode = "INVALID_TOKEN"
+	ErrCodeInsufficientRole        ErrorCode = "INSUFFICIENT_ROLE"
+	ErrCodeUniversityAccessDenied  ErrorCode = "UNIVERSITY_ACCESS_DENIED"
+	ErrCodeAllUniversitiesRequired ErrorCode = "ALL_UNIVERSITIES_REQUIRED"
+)
+
+// Ресурсы
+const (
+	ErrCodeFileNotFound       ErrorCode = "FILE_NOT_FOUND"
+	ErrCodeSourceNotFound     ErrorCode = "SOURCE_FILE_NOT_FOUND"
+	ErrCodeVersionNotFound    ErrorCode = "VERSION_NOT_FOUND"
+	ErrCodeNoPreviousVersion  ErrorCode = "NO_PREVIOUS_VERSION"
+	ErrCodeDiffUnsupported    ErrorCode = "DIFF_UNSUPPORTED"
+	ErrCodeExamsUnsupported   ErrorCode = "EXAMS_UNSUPPORTED"
+	ErrCodeGroupNotFound      ErrorCode = "GROUP_NOT_FOUND"
+	ErrCodeTeacherNotFound    ErrorCode = "TEACHER_NOT_FOUND"
+	ErrCodeWebhookNotFound    ErrorCode = "WEBHOOK_NOT_FOUND"
+	ErrCodeDeadLetterNotFound ErrorCode = "DEAD_LETTER_NOT_FOUND"
+)
+
+// Разбор XLSX
+const (
+	ErrCodeParseInvalidXLSX ErrorCode = "PARSE_INVALID_XLSX"
+	ErrCodeParseNoSheets    ErrorCode = "PARSE_NO_SHEETS"
+	ErrCodeParseTooFewRows  ErrorCode = "PARSE_TOO_FEW_ROWS"
+	ErrCodeParseNoGroups    ErrorCode = "PARSE_NO_GROUPS"
+	ErrCodeParseUnknownType ErrorCode = "PARSE_UNKNOWN_SCHEDULE_TYPE"
+	ErrCodeParseFailed      ErrorCode = "PARSE_FAILED"
+)
+
+// Хранилище и сервис
+const (
+	ErrCodeStorageUnavailable  ErrorCode = "STORAGE_UNAVAILABLE"
+	ErrCodeStorageTimeout      ErrorCode = "STORAGE_TIMEOUT"
+	ErrCodeStorageAccessDenied ErrorCode = "STORAGE_ACCESS_DENIED"
+	ErrCodeStorageError        ErrorCode = "STORAGE_ERROR"
+	ErrCodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// Языки сообщений об ошибках
+const (
+	LanguageEnglish = "en"
+	LanguageRussian = "ru"
+)
+
+// errorInfo — HTTP-статус и шаблоны сообщения; аргументы подставляются через fmt
+type errorInfo struct {
+	status int
+	en     string
+	ru     string
+}
+
+var errorCatalog = map[ErrorCode]errorInfo{
+	ErrCodeMissingParameter:   {http.StatusBadRequest, "required parameters are missing: %s", "не указаны обязательные параметры: %s"},
+	ErrCodeInvalidParameter:   {http.StatusBadRequest, "invalid %s parameter", "некорректный параметр %s"},
+	ErrCodeInvalidLimit:       {http.StatusBadRequest, "limit must be between 1 and %d", "limit должен быть от 1 до %d"},
+	ErrCodeInvalidPeriod:      {http.StatusBadRequest, "from must not be after to and the period must not exceed %d days", "from не может быть позже to, а период — длиннее %d дней"},
+	ErrCodeInvalidSort:        {http.StatusBadRequest, "sort must be one of %s, optionally prefixed with '-'", "sort должен быть одним из значений %s, с '-' — по убыванию"},
+	ErrCodeInvalidCursor:      {http.StatusBadRequest, "invalid cursor, it must come from a previous page with the same sort and name filter", "некорректный cursor: он должен быть взят из предыдущей страницы с той же сортировкой и фильтром name"},
+	ErrCodeInvalidRequestBody: {http.StatusBadRequest, "invalid request body: %s", "некорректное тело запроса: %s"},
+	ErrCodeRateLimitExceeded:  {http.StatusTooManyRequests, "too many %s requests, retry in %ds", "слишком много запросов (%s), повторите через %d с"},
+
+	ErrCodeAuthenticationRequired:  {http.StatusUnauthorized, "authentication required", "требуется аутентификация"},
+	ErrCodeInvalidAPIKey:           {http.StatusUnauthorized, "invalid api key", "неверный API-ключ"},
+	ErrCodeInvalidToken:            {http.StatusUnauthorized, "invalid bearer token", "недействительный токен"},
+	ErrCodeInsufficientRole:        {http.StatusForbidden, "insufficient role, required role: %s", "недостаточно прав, требуется роль %s"},
+	ErrCodeUniversityAccessDenied:  {http.StatusForbidden, "access to university %s denied", "нет доступа к университету %s"},
+	ErrCodeAllUniversitiesRequired: {http.StatusForbidden, "this action requires access to all universities", "для этого действия нужен доступ ко всем университетам"},
+
+	ErrCodeFileNotFound:       {http.StatusNotFound, "file not found", "файл не найден"},
+	ErrCodeSourceNotFound:     {http.StatusNotFound, "uploaded file not found: %s", "загруженный файл не найден: %s"},
+	ErrCodeVersionNotFound:    {http.StatusNotFound, "file version not found", "версия файла не найдена"},
+	ErrCodeNoPreviousVersion:  {http.StatusNotFound, "no previous version to compare with", "нет предыдущей версии для сравнения"},
+	ErrCodeDiffUnsupported:    {http.StatusUnprocessableEntity, "diff is only available for regular schedules", "сравнение доступно только для основного расписания"},
+	ErrCodeExamsUnsupported:   {http.StatusUnprocessableEntity, "exam sessions are only available for exam schedules", "экзаменационная сессия доступна только для расписания экзаменов"},
+	ErrCodeGroupNotFound:      {http.StatusNotFound, "group %s not found in the schedule", "группа %s не найдена в расписании"},
+	ErrCodeTeacherNotFound:    {http.StatusNotFound, "teacher not found: %s", "преподаватель не найден: %s"},
+	ErrCodeWebhookNotFound:    {http.StatusNotFound, "webhook subscription not found", "подписка не найдена"},
+	ErrCodeDeadLetterNotFound: {http.StatusNotFound, "dead-letter delivery not found", "доставка не найдена среди неотправленных"},
+
+	ErrCodeParseInvalidXLSX: {http.StatusUnprocessableEntity, "file is not a valid xlsx workbook", "файл не является книгой XLSX"},
+	ErrCodeParseNoSheets:    {http.StatusUnprocessableEntity, "workbook has no sheets", "в книге нет листов"},
+	ErrCodeParseTooFewRows:  {http.StatusUnprocessableEntity, "sheet has too few rows for this schedule type", "на листе слишком мало строк для этого типа расписания"},
+	ErrCodeParseNoGroups:    {http.StatusUnprocessableEntity, "no groups found in the group row", "в строке групп не найдено ни одной группы"},
+	ErrCodeParseUnknownType: {http.StatusUnprocessableEntity, "unknown schedule type %s", "неизвестный тип расписания %s"},
+	ErrCodeParseFailed:      {http.StatusUnprocessableEntity, "failed to parse schedule file", "не удалось разобрать файл расписания"},
+
+	ErrCodeStorageUnavailable:  {http.StatusServiceUnavailable, "storage is unavailable, try again later", "хранилище недоступно, повторите позже"},
+	ErrCodeStorageTimeout:      {http.StatusGatewayTimeout, "storage did not respond in time", "хранилище не ответило вовремя"},
+	ErrCodeStorageAccessDenied: {http.StatusBadGateway, "storage denied access", "хранилище отказало в доступе"},
+	ErrCodeStorageError:        {http.StatusBadGateway, "storage request failed", "ошибка запроса к хранилищу"},
+	ErrCodeInternal:            {http.StatusInternalServerError, "internal server error", "внутренняя ошибка сервера"},
+}
+
+// Status возвращает HTTP-статус ошибки
+func (c ErrorCode) Status() int {
+	if info, ok := errorCatalog[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message возвращает сообщение об ошибке на языке lang (ru или en, по умолчанию английский)
+func (c ErrorCode) Message(lang string, args ...any) string {
+	info, ok := errorCatalog[c]
+	if !ok {
+		info = errorCatalog[ErrCodeInternal]
+	}
+	template := info.en
+	if lang == LanguageRussian {
+		template = info.ru
+	}
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"testing"
+)
+
+// verb — подстановка fmt; %% подстановкой не считается
+var verb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func countVerbs(template string) int {
+	n := 0
+	for _, match := range verb.FindAllString(template, -1) {
+		if match != "%%" {
+			n++
+		}
+	}
+	return n
+}
+
+// declaredCodes возвращает все константы типа ErrorCode из errors.go
+func declaredCodes(t *testing.T) []ErrorCode {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []ErrorCode
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "ErrorCode" {
+				continue
+			}
+			for _, name := range value.Values {
+				lit := name.(*ast.BasicLit)
+				codes = append(codes, ErrorCode(lit.Value[1:len(lit.Value)-1]))
+			}
+		}
+	}
+	if len(codes) == 0 {
+		t.Fatal("no ErrorCode constants found in errors.go")
+	}
+	return codes
+}
+
+func TestErrorCatalogComplete(t *testing.T) {
+	codes := declaredCodes(t)
+	if len(codes) != len(errorCatalog) {
+		t.Errorf("%d codes declared, %d in the catalog", len(codes), len(errorCatalog))
+	}
+
+	for _, code := range codes {
+		info, ok := errorCatalog[code]
+		if !ok {
+			t.Errorf("%s: no catalog entry", code)
+			continue
+		}
+		if info.status < 400 || info.status > 599 {
+			t.Errorf("%s: status %d is not an error status", code, info.status)
+		}
+		if info.en == "" || info.ru == "" {
+			t.Errorf("%s: missing translation (en %q, ru %q)", code, info.en, info.ru)
+		}
+		if en, ru := countVerbs(info.en), countVerbs(info.ru); en != ru {
+			t.Errorf("%s: %d verbs in en, %d in ru", code, en, ru)
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		code ErrorCode
+		lang string
+		args []any
+		want string
+	}{
+		{ErrCodeFileNotFound, LanguageEnglish, nil, "file not found"},
+		{ErrCodeFileNotFound, LanguageRussian, nil, "файл не найден"},
+		{ErrCodeFileNotFound, "de", nil, "file not found"},
+		{ErrCodeInvalidLimit, LanguageRussian, []any{100}, "limit должен быть от 1 до 100"},
+		{ErrCodeRateLimitExceeded, LanguageEnglish, []any{"read", 12}, "too many read requests, retry in 12s"},
+		{ErrorCode("UNKNOWN"), LanguageRussian, nil, "внутренняя ошибка сервера"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.code.Message(tt.lang, tt.args...); got != tt.want {
+			t.Errorf("%s.Message(%q) = %q, want %q", tt.code, tt.lang, got, tt.want)
+		}
+	}
+	if status := ErrorCode("UNKNOWN").Status(); status != 500 {
+		t.Errorf("unknown code status = %d, want 500", status)
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Типы событий шины обновлений
+const (
+	EventScheduleProcessed = "schedule.processed"
+	EventVersionRestored   = "schedule.version_restored"
+	EventCacheInvalidated  = "cache.invalidated"
+)
+
+// Event — событие об изменении расписаний. Пустые University, Course, ScheduleType и Groups
+// означают, что событие касается всех (например, полный сброс кэша)
+type Event struct {
+	ID           string       `json:"id"`
+	Type         string       `json:"type"`
+	Time         time.Time    `json:"time"`
+	University   string       `json:"university,omitempty"`
+	Course       string       `json:"course,omitempty"`
+	ScheduleType string       `json:"scheduleType,omitempty"`
+	File         string       `json:"file,omitempty"`
+	Version      string       `json:"version,omitempty"`
+	Groups       []string     `json:"groups,omitempty"`   // Группы с изменениями, если они известны
+	Teachers     []string     `json:"teachers,omitempty"` // Преподаватели, чьих занятий касаются изменения
+	Dates        []string     `json:"dates,omitempty"`    // Даты замен (YYYY-MM-DD) для расписания замен
+	Summary      *DiffSummary `json:"summary,omitempty"`
+}
+
+// Unscoped сообщает, что список затронутых групп и преподавателей у события не может быть известен
+// (откат версии, сброс кэша) — такое событие получают все подписчики
+func (e Event) Unscoped() bool {
+	return e.Type == EventVersionRestored || e.Type == EventCacheInvalidated
+}
+
+// EventFilter — условия подписки на события; пустое поле не фильтрует
+type EventFilter struct {
+	University   string
+	Course       string
+	ScheduleType string
+	Group        string
+	Types        []string
+}
+
+// Matches сообщает, нужно ли отправить событие подписчику
+func (f EventFilter) Matches(e Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	return matchesField(f.University, e.University) &&
+		matchesField(f.Course, e.Course) &&
+		matchesField(f.ScheduleType, e.ScheduleType) &&
+		f.matchesGroup(e)
+}
+
+// matchesGroup: событие проходит фильтр по группе, только если перечисляет эту группу.
+// Событие без области (откат версии, сброс кэша) может касаться любой группы
+func (f EventFilter) matchesGroup(e Event) bool {
+	return f.Group == "" || e.Unscoped() || slices.Contains(e.Groups, f.Group)
+}
+
+func matchesField(filter, value string) bool {
+	return filter == "" || value == "" || filter == value
+}
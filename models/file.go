@@ -25,40 +25,91 @@ type DaySchedule struct {
 }
 
 type Lesson struct {
-	Time      string `json:"time"`
-	Subject   string `json:"subject"`
-	Teacher   string `json:"teacher"`
-	Type      string `json:"type"`
-	Classroom string `json:"classroom"`
-	SubGroup  string `json:"subGroup"`
+	Time       string   `json:"time"`
+	Subject    string   `json:"subject"`
+	Teacher    string   `json:"teacher"`              // Первый (основной) преподаватель
+	TeacherID  string   `json:"teacherId,omitempty"`  // Идентификатор в справочнике преподавателей
+	Teachers   []string `json:"teachers,omitempty"`   // Все преподаватели, в том числе при совместном занятии
+	TeacherIDs []string `json:"teacherIds,omitempty"` // Идентификаторы Teachers в том же порядке
+	Type       string   `json:"type"`
+	Classroom  string   `json:"classroom"`
+	SubGroup   string   `json:"subGroup"`
+}
+
+// AllTeachers возвращает всех преподавателей занятия, в том числе из JSON без списка Teachers
+func (l Lesson) AllTeachers() []string {
+	if len(l.Teachers) > 0 {
+		return l.Teachers
+	}
+	if l.Teacher != "" {
+		return []string{l.Teacher}
+	}
+	return nil
 }
 
 type ReplacementSchedule struct {
 	Type         string        `json:"type"`
-	Date         string        `json:"date"`
+	Date         string        `json:"date"`            // Первая дата замен в файле, YYYY-MM-DD; пусто, если дата не указана
+	Dates        []string      `json:"dates,omitempty"` // Все даты замен в файле по возрастанию
 	UpdatedAt    time.Time     `json:"updatedAt"`
+	Groups       []string      `json:"groups,omitempty"`
 	Replacements []Replacement `json:"replacements"`
 }
 
+// Статусы замены
+const (
+	ReplacementStatusReplaced  = "replaced"  // занятие заменено (дисциплина, преподаватель или аудитория)
+	ReplacementStatusCancelled = "cancelled" // занятие отменено
+)
+
 type Replacement struct {
-	Time            string `json:"time"`
-	OriginalSubject string `json:"originalSubject"`
-	NewSubject      string `json:"newSubject"`
-	OriginalTeacher string `json:"originalTeacher"`
-	NewTeacher      string `json:"newTeacher"`
-	Classroom       string `json:"classroom"`
+	Date              string `json:"date,omitempty"`
+	Group             string `json:"group,omitempty"`
+	Time              string `json:"time"`
+	OriginalSubject   string `json:"originalSubject"`
+	NewSubject        string `json:"newSubject"`
+	OriginalTeacher   string `json:"originalTeacher"`
+	OriginalTeacherID string `json:"originalTeacherId,omitempty"`
+	NewTeacher        string `json:"newTeacher"`
+	NewTeacherID      string `json:"newTeacherId,omitempty"`
+	Classroom         string `json:"classroom"`
+	Status            string `json:"status"`
+	Reason            string `json:"reason,omitempty"`
 }
 
 type ExamSchedule struct {
 	Type      string    `json:"type"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	StartDate string    `json:"startDate,omitempty"` // Первый день сессии, YYYY-MM-DD
+	EndDate   string    `json:"endDate,omitempty"`   // Последний день сессии, YYYY-MM-DD
+	Groups    []string  `json:"groups,omitempty"`
 	Exams     []Exam    `json:"exams"`
 }
 
+// Виды экзаменационных мероприятий
+const (
+	ExamKindExam         = "exam"          // экзамен
+	ExamKindCredit       = "credit"        // зачёт
+	ExamKindGradedCredit = "graded_credit" // дифф. зачёт
+	ExamKindConsultation = "consultation"  // консультация
+)
+
 type Exam struct {
-	Date      string `json:"date"`
+	Date      string `json:"date"`              // YYYY-MM-DD; исходный текст, если дату разобрать не удалось
+	EndDate   string `json:"endDate,omitempty"` // Последний день мероприятия, идущего несколько дней
 	Time      string `json:"time"`
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
 	Subject   string `json:"subject"`
 	Teacher   string `json:"teacher"`
+	TeacherID string `json:"teacherId,omitempty"`
 	Classroom string `json:"classroom"`
 }
+
+// ExamSession — экзаменационная сессия одной группы в хронологическом порядке
+type ExamSession struct {
+	Group     string `json:"group"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+	Exams     []Exam `json:"exams"`
+}
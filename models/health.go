@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// Liveness — ответ /healthz
+type Liveness struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// ReadinessResponse — ответ /readyz с состоянием каждой зависимости
+type ReadinessResponse struct {
+	Status string                      `json:"status"`
+	Time   time.Time                   `json:"time"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	Target    string  `json:"target,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Response — общий конверт успешного ответа API
+type Response[T any] struct {
+	Data      T      `json:"data"`
+	Meta      *Meta  `json:"meta,omitempty"` // Только для списков
+	Cached    bool   `json:"cached"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Message — данные ответа на действие, у которого нет другого результата
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Meta — сведения о странице списка
+type Meta struct {
+	Total      *int   `json:"total,omitempty"` // Нет, если страница прочитана из MinIO без полного списка
+	Limit      int    `json:"limit,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"` // Пусто на последней странице
+}
+
+// TotalMeta описывает список, отданный целиком
+func TotalMeta(total int) *Meta {
+	return &Meta{Total: &total}
+}
@@ -26,13 +26,31 @@ type ScheduleFile struct {
 	Version      string    `json:"version,omitempty"`
 }
 
+// FileVersion — версия файла расписания в версионируемом бакете
+type FileVersion struct {
+	Version        string    `json:"version"`
+	Size           int64     `json:"size"`
+	LastModified   time.Time `json:"lastModified"`
+	ETag           string    `json:"etag"`
+	IsLatest       bool      `json:"isLatest"`
+	IsDeleteMarker bool      `json:"isDeleteMarker,omitempty"`
+}
+
+// RestoredVersion — результат восстановления: File описывает новую текущую версию
+type RestoredVersion struct {
+	RestoredVersion string       `json:"restoredVersion"`
+	File            *FileVersion `json:"file"`
+}
+
 type PresignedURLResponse struct {
 	URL       string    `json:"url"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	FileName  string    `json:"fileName"`
 }
 
+// ErrorResponse — ответ с ошибкой: стабильный код и сообщение на языке клиента
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Code      ErrorCode `json:"code"`
+	Error     string    `json:"error"`
+	RequestID string    `json:"requestId,omitempty"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Teacher — преподаватель из справочника. Один и тот же преподаватель из разных файлов
+// и в разных написаниях ("Гареева Г. А.", "Гареева Г.А") получает один ID
+type Teacher struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`                 // Нормализованное имя: "Гареева Г.А."
+	FullName   string   `json:"fullName,omitempty"`   // Из сопоставления, загруженного администратором
+	Department string   `json:"department,omitempty"` // Из сопоставления, загруженного администратором
+	Variants   []string `json:"variants,omitempty"`   // Другие написания (Ё/Е), встреченные в файлах
+}
+
+// TeacherMapping — строка сопоставления: краткое имя в любом написании или ID → ФИО и кафедра
+type TeacherMapping struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name,omitempty" binding:"required_without=ID"`
+	FullName   string `json:"fullName"`
+	Department string `json:"department"`
+}
+
+// TeacherMappingRequest — сопоставление целиком; заменяет ранее загруженное
+type TeacherMappingRequest struct {
+	Teachers []TeacherMapping `json:"teachers" binding:"required,dive"`
+}
+
+// teacherNamePattern — "Фамилия И.О." с любыми пробелами и точками: "Гареева Г. А.", "Гареева Г.А", "Гареева ГА"
+var teacherNamePattern = regexp.MustCompile(`^([А-ЯЁ][а-яё]+(?:-[А-ЯЁ][а-яё]+)?)\s*([А-ЯЁ])\s*\.?\s*(?:([А-ЯЁ])\s*\.?)?$`)
+
+// NormalizeTeacherName приводит имя к виду "Фамилия И.О.": один пробел после фамилии,
+// инициалы с точками без пробелов. Имена другого вида возвращаются со схлопнутыми пробелами
+func NormalizeTeacherName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	m := teacherNamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return name
+	}
+
+	normalized := m[1] + " " + m[2] + "."
+	if m[3] != "" {
+		normalized += m[3] + "."
+	}
+	return normalized
+}
+
+// TeacherKey возвращает ключ для сравнения имён: нормализованное имя без учёта регистра и Ё
+func TeacherKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(NormalizeTeacherName(name)), "ё", "е")
+}
+
+// TeacherID возвращает стабильный идентификатор преподавателя: он зависит только от ключа имени,
+// поэтому совпадает во всех файлах и после перезапуска. Для пустого имени возвращает пустую строку
+func TeacherID(name string) string {
+	key := TeacherKey(name)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+// SameTeacher сообщает, что имя относится к преподавателю, заданному именем в любом написании или ID
+func SameTeacher(name, teacher string) bool {
+	key := TeacherKey(name)
+	return key != "" && (key == TeacherKey(teacher) || TeacherID(name) == teacher)
+}
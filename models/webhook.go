@@ -0,0 +1,127 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// События, о которых сообщают webhook'и
+const (
+	WebhookEventScheduleChanged = "schedule.changed"
+)
+
+// Состояния доставки webhook'а
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // Попытка не удалась, будет повтор
+	DeliveryDead      = "dead"   // Попытки исчерпаны, доставка перенесена в dead-letter
+)
+
+// WebhookSubscription — подписка внешней системы на изменения расписаний.
+// Пустые фильтры означают «любой»; Group и Teacher сужают diff до нужных занятий
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url" binding:"required,url"`
+	Secret     string    `json:"secret,omitempty"` // Ключ подписи HMAC-SHA256; в списках не отдаётся
+	University string    `json:"university,omitempty"`
+	Course     string    `json:"course,omitempty"`
+	Group      string    `json:"group,omitempty"`
+	Teacher    string    `json:"teacher,omitempty"` // Имя в любом написании или ID из справочника
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  string    `json:"createdBy,omitempty"`
+}
+
+// Matches сообщает, относится ли изменение файла к подписке (без учёта Group и Teacher)
+func (s WebhookSubscription) Matches(university, course string) bool {
+	return (s.University == "" || s.University == university) &&
+		(s.Course == "" || s.Course == course)
+}
+
+// FilterDiff оставляет в diff только изменения группы и преподавателя подписки
+func (s WebhookSubscription) FilterDiff(diff *ScheduleDiff) *ScheduleDiff {
+	if diff == nil || (s.Group == "" && s.Teacher == "") {
+		return diff
+	}
+
+	filtered := *diff
+	filtered.Changes = make([]LessonChange, 0)
+	filtered.Summary = DiffSummary{Groups: make([]string, 0)}
+	for _, change := range diff.Changes {
+		if s.Group != "" && change.Group != s.Group {
+			continue
+		}
+		if s.Teacher != "" && !change.involvesTeacher(s.Teacher) {
+			continue
+		}
+		filtered.Changes = append(filtered.Changes, change)
+		switch change.Kind {
+		case LessonAdded:
+			filtered.Summary.Added++
+		case LessonRemoved:
+			filtered.Summary.Removed++
+		case LessonModified:
+			filtered.Summary.Modified++
+		}
+		if n := len(filtered.Summary.Groups); n == 0 || filtered.Summary.Groups[n-1] != change.Group {
+			filtered.Summary.Groups = append(filtered.Summary.Groups, change.Group)
+		}
+	}
+	return &filtered
+}
+
+// Involves сообщает, есть ли группа и преподаватель подписки среди затронутых файлом
+func (s WebhookSubscription) Involves(groups, teachers []string) bool {
+	if s.Group != "" && !slices.Contains(groups, s.Group) {
+		return false
+	}
+	if s.Teacher != "" && !slices.ContainsFunc(teachers, func(name string) bool { return SameTeacher(name, s.Teacher) }) {
+		return false
+	}
+	return true
+}
+
+func (c LessonChange) involvesTeacher(teacher string) bool {
+	for _, lesson := range []*Lesson{c.Before, c.After} {
+		if lesson == nil {
+			continue
+		}
+		for _, name := range lesson.AllTeachers() {
+			if SameTeacher(name, teacher) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WebhookPayload — тело запроса, отправляемого подписчику
+type WebhookPayload struct {
+	ID           string        `json:"id"`
+	Event        string        `json:"event"`
+	Time         time.Time     `json:"time"`
+	University   string        `json:"university"`
+	Course       string        `json:"course"`
+	ScheduleType string        `json:"scheduleType"`
+	File         string        `json:"file"`
+	Version      string        `json:"version,omitempty"`
+	Diff         *ScheduleDiff `json:"diff,omitempty"`     // Только для основного расписания
+	Groups       []string      `json:"groups,omitempty"`   // Группы файла замен или экзаменов, для которых diff не строится
+	Teachers     []string      `json:"teachers,omitempty"` // Преподаватели файла замен или экзаменов
+}
+
+// WebhookDelivery — запись журнала доставки
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	URL            string          `json:"url"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	StatusCode     int             `json:"statusCode,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	Payload        *WebhookPayload `json:"payload,omitempty"`
+}
@@ -0,0 +1,90 @@
+// Package openapi встраивает в сервис описание API в формате OpenAPI 3 и страницу документации
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed ui.html
+var uiHTML []byte
+
+// Spec — разобранный документ OpenAPI
+type Spec struct {
+	json  []byte
+	paths map[string]map[string]bool // Путь в нотации OpenAPI -> методы в нижнем регистре
+}
+
+type document struct {
+	Paths map[string]map[string]interface{} `yaml:"paths"`
+}
+
+// Load разбирает встроенный документ и готовит его JSON-представление
+func Load() (*Spec, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(specYAML, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi.yaml: %w", err)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi spec: %w", err)
+	}
+
+	var doc document
+	if err := yaml.Unmarshal(specYAML, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi paths: %w", err)
+	}
+	paths := make(map[string]map[string]bool, len(doc.Paths))
+	for path, item := range doc.Paths {
+		methods := make(map[string]bool, len(item))
+		for method := range item {
+			methods[strings.ToLower(method)] = true
+		}
+		paths[path] = methods
+	}
+
+	return &Spec{json: data, paths: paths}, nil
+}
+
+// Undocumented возвращает маршруты роутера, которых нет в документе, в виде "GET /path"
+func (s *Spec) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		if !s.paths[specPath(route.Path)][strings.ToLower(route.Method)] {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// ServeJSON отдаёт документ OpenAPI
+func (s *Spec) ServeJSON(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", s.json)
+}
+
+// ServeUI отдаёт страницу Swagger UI, которая читает openapi.json рядом с собой
+func (s *Spec) ServeUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", uiHTML)
+}
+
+// specPath переводит путь gin (/files/:filename, /static/*path) в нотацию OpenAPI (/files/{filename})
+func specPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"schedule-api/logging"
+	"schedule-api/models"
+)
+
+const auditDefaultPeriod = 7 * 24 * time.Hour
+
+// AuditService ведёт журнал административных действий.
+// Каждый вызов Record создаёт новый JSONL-объект под префиксом prefix/YYYY/MM/DD/,
+// существующие объекты никогда не перезаписываются
+type AuditService struct {
+	minio  *MinIOService
+	bucket string
+	prefix string
+}
+
+func NewAuditService(minio *MinIOService, bucket, prefix string) *AuditService {
+	return &AuditService{
+		minio:  minio,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/") + "/",
+	}
+}
+
+// Record сохраняет записи журнала одним объектом
+func (s *AuditService) Record(ctx context.Context, entries ...models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for i := range entries {
+		if entries[i].ID == "" {
+			entries[i].ID = newID()
+		}
+		if entries[i].Time.IsZero() {
+			entries[i].Time = now
+		}
+		if err := encoder.Encode(entries[i]); err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+	}
+
+	objectPath := fmt.Sprintf("%s%s%d-%s.jsonl", s.prefix, now.Format("2006/01/02/"), now.UnixNano(), entries[0].ID)
+	if _, err := s.minio.UploadFile(ctx, s.bucket, objectPath, &buf, int64(buf.Len()), "application/x-ndjson"); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Query читает журнал за интервал дат фильтра и возвращает записи от новых к старым
+func (s *AuditService) Query(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	to := filter.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := filter.From
+	if from.IsZero() {
+		from = to.Add(-auditDefaultPeriod)
+	}
+
+	entries := make([]models.AuditEntry, 0)
+	for day := truncateDay(from.UTC()); !day.After(to.UTC()); day = day.AddDate(0, 0, 1) {
+		objects, err := s.minio.ListAllObjectsInBucket(ctx, s.bucket, s.prefix+day.Format("2006/01/02/"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit log: %w", err)
+		}
+
+		for _, objectPath := range objects {
+			data, err := s.minio.DownloadFile(ctx, s.bucket, objectPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read audit log: %w", err)
+			}
+
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				var entry models.AuditEntry
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					logging.FromContext(ctx).Warn("skipping malformed audit entry", "path", objectPath, "error", err)
+					continue
+				}
+				if entry.Time.Before(from) || entry.Time.After(to) || !filter.Matches(entry) {
+					continue
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"schedule-api/models"
+)
+
+func TestAuditRecord(t *testing.T) {
+	minio, server := newTestMinIO(t)
+	audit := NewAuditService(minio, "schedule-api", "/audit/")
+
+	if err := audit.Record(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if objects := server.Objects("schedule-api"); len(objects) != 0 {
+		t.Fatalf("empty Record wrote %v", objects)
+	}
+
+	before := time.Now().UTC()
+	err := audit.Record(t.Context(),
+		models.AuditEntry{Actor: "admin", Action: models.AuditActionCacheInvalidate, Result: models.AuditResultSuccess},
+		models.AuditEntry{ID: "given", Actor: "admin", Action: models.AuditActionRestoreVersion, University: "kgu", Result: models.AuditResultFailure},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Записи одного вызова — один объект JSONL под префиксом дня записи
+	objects := server.Objects("schedule-api")
+	if len(objects) != 1 {
+		t.Fatalf("objects = %v, want one", objects)
+	}
+	pattern := regexp.MustCompile(`^audit/` + before.Format("2006/01/02") + `/\d+-[0-9a-f]{16}\.jsonl$`)
+	if !pattern.MatchString(objects[0]) {
+		t.Errorf("object path %q does not match %s", objects[0], pattern)
+	}
+
+	data, _ := server.Get("schedule-api", objects[0])
+	var written []models.AuditEntry
+	for scanner := bufio.NewScanner(bytes.NewReader(data)); scanner.Scan(); {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		written = append(written, entry)
+	}
+	if len(written) != 2 || written[0].ID == "" || written[1].ID != "given" || written[0].Time.Before(before) || !written[0].Time.Equal(written[1].Time) {
+		t.Errorf("written entries = %+v", written)
+	}
+
+	// Каждый вызов создаёт новый объект, прежние не перезаписываются
+	if err := audit.Record(t.Context(), models.AuditEntry{Actor: "uploader", Action: models.AuditActionCacheInvalidate}); err != nil {
+		t.Fatal(err)
+	}
+	if objects := server.Objects("schedule-api"); len(objects) != 2 {
+		t.Errorf("objects after the second Record = %v, want 2", objects)
+	}
+
+	entries, err := audit.Query(t.Context(), models.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Actor != "uploader" {
+		t.Errorf("Query = %+v, want 3 entries, newest first", entries)
+	}
+}
+
+func TestAuditQuery(t *testing.T) {
+	minio, server := newTestMinIO(t)
+	audit := NewAuditService(minio, "schedule-api", "audit")
+
+	at := func(day, hour int) time.Time { return time.Date(2026, 1, day, hour, 0, 0, 0, time.UTC) }
+	put := func(path string, entries ...models.AuditEntry) {
+		var buf bytes.Buffer
+		for _, entry := range entries {
+			_ = json.NewEncoder(&buf).Encode(entry)
+		}
+		server.Put("schedule-api", path, buf.Bytes())
+	}
+	put("audit/2026/01/09/1-a.jsonl", models.AuditEntry{ID: "jan9", Time: at(9, 12), Actor: "admin", University: "kgu"})
+	put("audit/2026/01/10/1-b.jsonl",
+		models.AuditEntry{ID: "jan10-early", Time: at(10, 6), Actor: "admin", University: "kgu"},
+		models.AuditEntry{ID: "jan10", Time: at(10, 12), Actor: "uploader", University: "agtu", Result: models.AuditResultFailure},
+	)
+	put("audit/2026/01/11/1-c.jsonl", models.AuditEntry{ID: "jan11", Time: at(11, 12), Actor: "admin", University: "kgu"})
+	server.Put("schedule-api", "audit/2026/01/11/2-d.jsonl", []byte("not json\n"))
+	put("audit/2026/01/12/1-e.jsonl", models.AuditEntry{ID: "jan12", Time: at(12, 12), Actor: "admin"})
+	put("diffs/2026/01/11/1-f.jsonl", models.AuditEntry{ID: "other prefix", Time: at(11, 13), Actor: "admin"})
+
+	tests := []struct {
+		name   string
+		filter models.AuditFilter
+		want   []string
+	}{
+		{
+			name:   "interval within days",
+			filter: models.AuditFilter{From: at(10, 8), To: at(11, 23)},
+			want:   []string{"jan11", "jan10"},
+		},
+		{
+			name:   "whole days",
+			filter: models.AuditFilter{From: at(9, 0), To: at(12, 23)},
+			want:   []string{"jan12", "jan11", "jan10", "jan10-early", "jan9"},
+		},
+		{
+			name:   "default period ends at to",
+			filter: models.AuditFilter{To: at(10, 23)},
+			want:   []string{"jan10", "jan10-early", "jan9"},
+		},
+		{
+			name:   "actor",
+			filter: models.AuditFilter{From: at(9, 0), To: at(12, 23), Actor: "admin"},
+			want:   []string{"jan12", "jan11", "jan10-early", "jan9"},
+		},
+		{
+			name:   "result and university",
+			filter: models.AuditFilter{From: at(9, 0), To: at(12, 23), University: "agtu", Result: models.AuditResultFailure},
+			want:   []string{"jan10"},
+		},
+		{
+			name:   "university scope skips entries without university",
+			filter: models.AuditFilter{From: at(9, 0), To: at(12, 23), Universities: []string{"kgu"}},
+			want:   []string{"jan11", "jan10-early", "jan9"},
+		},
+		{
+			name:   "limit keeps the newest",
+			filter: models.AuditFilter{From: at(9, 0), To: at(12, 23), Limit: 2},
+			want:   []string{"jan12", "jan11"},
+		},
+		{
+			name:   "empty period",
+			filter: models.AuditFilter{From: at(1, 0), To: at(5, 0)},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := audit.Query(t.Context(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				got = append(got, entry.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Query = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
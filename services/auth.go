@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"schedule-api/config"
+	"schedule-api/models"
+)
+
+type AuthService struct {
+	keys     []models.APIKey
+	verifier *JWTVerifier
+}
+
+// NewAuthService создаёт сервис аутентификации; verifier может быть nil, если SSO не настроен
+func NewAuthService(keys []models.APIKey, verifier *JWTVerifier) *AuthService {
+	return &AuthService{keys: keys, verifier: verifier}
+}
+
+// LoadAPIKeys читает ключи из файла и/или объекта в MinIOBucket, указанных в конфигурации
+func LoadAPIKeys(ctx context.Context, cfg *config.Config, minio *MinIOService) ([]models.APIKey, error) {
+	var keys []models.APIKey
+
+	if cfg.APIKeysFile != "" {
+		data, err := os.ReadFile(cfg.APIKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read api keys file: %w", err)
+		}
+		fileKeys, err := parseAPIKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api keys file %s: %w", cfg.APIKeysFile, err)
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	if cfg.APIKeysObject != "" {
+		data, err := minio.DownloadFile(ctx, cfg.MinIOBucket, cfg.APIKeysObject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download api keys object: %w", err)
+		}
+		objectKeys, err := parseAPIKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api keys object %s: %w", cfg.APIKeysObject, err)
+		}
+		keys = append(keys, objectKeys...)
+	}
+
+	return keys, nil
+}
+
+func parseAPIKeys(data []byte) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		if !key.Role.Valid() {
+			return nil, fmt.Errorf("key %q: unknown role %q", key.Name, key.Role)
+		}
+		hash := strings.ToLower(strings.TrimSpace(key.Hash))
+		if len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("key %q: hash must be a hex-encoded sha256", key.Name)
+		}
+		keys[i].Hash = hash
+	}
+	return keys, nil
+}
+
+// HashAPIKey возвращает SHA-256 ключа в hex — в таком виде ключи хранятся в конфигурации
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate ищет ключ по его хешу и возвращает соответствующего клиента
+func (s *AuthService) Authenticate(rawKey string) (*models.Principal, bool) {
+	if rawKey == "" {
+		return nil, false
+	}
+	hash := []byte(HashAPIKey(rawKey))
+
+	for _, key := range s.keys {
+		if subtle.ConstantTimeCompare(hash, []byte(key.Hash)) == 1 {
+			return &models.Principal{
+				Subject:      key.Name,
+				Role:         key.Role,
+				Universities: key.Universities,
+				AuthMethod:   "api_key",
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// AuthenticateToken проверяет bearer-токен SSO
+func (s *AuthService) AuthenticateToken(ctx context.Context, token string) (*models.Principal, error) {
+	if s.verifier == nil {
+		return nil, errors.New("bearer tokens are not accepted: JWKS is not configured")
+	}
+	return s.verifier.Verify(ctx, token)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"schedule-api/models"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	hash := HashAPIKey("secret")
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string // Подстрока ошибки; пусто — ключи корректны
+	}{
+		{name: "valid", data: `[{"name":"faculty","hash":"` + hash + `","role":"uploader","universities":["kgu"]}]`},
+		{name: "hash in upper case with spaces", data: `[{"name":"faculty","hash":" ` + strings.ToUpper(hash) + ` ","role":"admin"}]`},
+		{name: "unknown role", data: `[{"name":"faculty","hash":"` + hash + `","role":"owner"}]`, wantErr: "unknown role"},
+		{name: "raw key instead of hash", data: `[{"name":"faculty","hash":"secret","role":"reader"}]`, wantErr: "sha256"},
+		{name: "not json", data: `name=faculty`, wantErr: "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseAPIKeys([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != 1 || keys[0].Hash != hash {
+				t.Errorf("keys = %+v, want normalized hash %s", keys, hash)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	auth := NewAuthService([]models.APIKey{
+		{Name: "reader", Hash: HashAPIKey("reader-key"), Role: models.RoleReader},
+		{Name: "faculty", Hash: HashAPIKey("faculty-key"), Role: models.RoleUploader, Universities: []string{"kgu"}},
+	}, nil)
+
+	principal, ok := auth.Authenticate("faculty-key")
+	if !ok {
+		t.Fatal("valid key rejected")
+	}
+	if principal.Subject != "faculty" || principal.Role != models.RoleUploader || principal.AuthMethod != "api_key" ||
+		!principal.CanAccessUniversity("kgu") || principal.CanAccessUniversity("agtu") {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	// Сравнивается хеш ключа, а не сам ключ и не хеш, присланный клиентом
+	for _, key := range []string{"", "unknown", HashAPIKey("faculty-key"), "faculty-key "} {
+		if _, ok := auth.Authenticate(key); ok {
+			t.Errorf("key %q accepted", key)
+		}
+	}
+
+	if _, err := auth.AuthenticateToken(t.Context(), "token"); err == nil {
+		t.Error("bearer token accepted without JWKS")
+	}
+}
@@ -1,33 +1,236 @@
 package services
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"schedule-api/models"
+
 	"github.com/patrickmn/go-cache"
 )
 
 type CacheService struct {
-	cache *cache.Cache
+	cache      *cache.Cache
+	defaultTTL atomic.Int64
+
+	mu       sync.Mutex
+	families map[string]*models.CacheFamilyStats
+	deleting map[string]bool  // Ключи, удаляемые явно: go-cache вызывает для них OnEvicted
+	sizes    map[string]int64 // Размер записей, оценённый при сохранении
 }
 
 func NewCacheService(defaultExpiration, cleanupInterval time.Duration) *CacheService {
-	return &CacheService{
-		cache: cache.New(defaultExpiration, cleanupInterval),
+	s := &CacheService{
+		cache:    cache.New(defaultExpiration, cleanupInterval),
+		families: make(map[string]*models.CacheFamilyStats),
+		deleting: make(map[string]bool),
+		sizes:    make(map[string]int64),
 	}
+	s.defaultTTL.Store(int64(defaultExpiration))
+	s.cache.OnEvicted(s.evicted)
+	return s
 }
 
 func (s *CacheService) Get(key string) (interface{}, bool) {
-	return s.cache.Get(key)
+	value, found := s.cache.Get(key)
+	s.record(key, func(f *models.CacheFamilyStats) {
+		if found {
+			f.Hits++
+		} else {
+			f.Misses++
+		}
+	})
+	return value, found
+}
+
+// Contains проверяет наличие ключа, не учитывая обращение в статистике
+func (s *CacheService) Contains(key string) bool {
+	_, found := s.cache.Get(key)
+	return found
 }
 
+// Set сохраняет значение; duration == 0 означает текущее время жизни по умолчанию
 func (s *CacheService) Set(key string, value interface{}, duration time.Duration) {
+	if duration == cache.DefaultExpiration {
+		duration = time.Duration(s.defaultTTL.Load())
+	}
+	// Размер оценивается один раз здесь, а не при каждом сборе метрик
+	size := approximateSize(key, value)
+	s.mu.Lock()
+	s.sizes[key] = size
+	s.mu.Unlock()
 	s.cache.Set(key, value, duration)
 }
 
+// SetDefaultTTL меняет время жизни новых записей (применяется при перезагрузке конфигурации)
+func (s *CacheService) SetDefaultTTL(ttl time.Duration) {
+	s.defaultTTL.Store(int64(ttl))
+}
+
+// Delete удаляет ключ; удаление учитывается как инвалидация, а не вытеснение
 func (s *CacheService) Delete(key string) {
+	s.mu.Lock()
+	s.deleting[key] = true
+	s.mu.Unlock()
+
 	s.cache.Delete(key)
+
+	s.mu.Lock()
+	delete(s.deleting, key)
+	s.mu.Unlock()
+}
+
+// DeleteList удаляет закэшированный список вместе с его страницами (ключи вида "<key>|...")
+func (s *CacheService) DeleteList(key string) {
+	s.Delete(key)
+	for item := range s.cache.Items() {
+		if strings.HasPrefix(item, key+"|") {
+			s.Delete(item)
+		}
+	}
 }
 
+// evicted вызывается go-cache при удалении записи: по истечении срока или из Delete
+func (s *CacheService) evicted(key string, _ interface{}) {
+	s.mu.Lock()
+	invalidated := s.deleting[key]
+	delete(s.sizes, key)
+	s.mu.Unlock()
+
+	s.record(key, func(f *models.CacheFamilyStats) {
+		if invalidated {
+			f.Invalidations++
+		} else {
+			f.Evictions++
+		}
+	})
+}
+
+// Flush удаляет все записи; сброс учитывается как инвалидация каждого ключа
 func (s *CacheService) Flush() {
+	// Flush не вызывает OnEvicted, поэтому учитываем удалённые ключи вручную
+	for key := range s.cache.Items() {
+		s.record(key, func(f *models.CacheFamilyStats) { f.Invalidations++ })
+	}
 	s.cache.Flush()
+
+	s.mu.Lock()
+	s.sizes = make(map[string]int64)
+	s.mu.Unlock()
+}
+
+// Stats возвращает счётчики попаданий/промахов/вытеснений/инвалидаций по семействам ключей
+func (s *CacheService) Stats() models.CacheStats {
+	items := s.cache.Items()
+
+	s.mu.Lock()
+	families := make(map[string]models.CacheFamilyStats, len(s.families))
+	for name, f := range s.families {
+		families[name] = *f
+	}
+	sizes := s.entrySizes(items)
+	s.mu.Unlock()
+
+	stats := models.CacheStats{
+		ItemCount: len(items),
+		Families:  families,
+	}
+	for key := range items {
+		size := sizes[key]
+		stats.ApproxBytes += size
+
+		family := families[cacheKeyFamily(key)]
+		family.Items++
+		family.ApproxBytes += size
+		families[cacheKeyFamily(key)] = family
+	}
+	for _, f := range families {
+		stats.Hits += f.Hits
+		stats.Misses += f.Misses
+		stats.Evictions += f.Evictions
+		stats.Invalidations += f.Invalidations
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// Keys возвращает список ключей кэша со временем истечения, отсортированный по имени
+func (s *CacheService) Keys() []models.CacheKeyInfo {
+	items := s.cache.Items()
+	s.mu.Lock()
+	sizes := s.entrySizes(items)
+	s.mu.Unlock()
+
+	keys := make([]models.CacheKeyInfo, 0, len(items))
+	for key, item := range items {
+		info := models.CacheKeyInfo{
+			Key:         key,
+			Family:      cacheKeyFamily(key),
+			ApproxBytes: sizes[key],
+		}
+		if item.Expiration > 0 {
+			expiresAt := time.Unix(0, item.Expiration)
+			info.ExpiresAt = &expiresAt
+		}
+		keys = append(keys, info)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// entrySizes возвращает сохранённые размеры записей items; вызывается под s.mu.
+// Запись, размер которой ещё не сохранён, оценивается по длине ключа
+func (s *CacheService) entrySizes(items map[string]cache.Item) map[string]int64 {
+	sizes := make(map[string]int64, len(items))
+	for key := range items {
+		size, ok := s.sizes[key]
+		if !ok {
+			size = int64(len(key))
+		}
+		sizes[key] = size
+	}
+	return sizes
+}
+
+func (s *CacheService) record(key string, update func(f *models.CacheFamilyStats)) {
+	family := cacheKeyFamily(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[family]
+	if !ok {
+		f = &models.CacheFamilyStats{}
+		s.families[family] = f
+	}
+	update(f)
+}
+
+// cacheKeyFamily возвращает семейство ключа: "universities", "courses:", "types:", "files:".
+// Страницы списка ("universities|...") относятся к семейству самого списка
+func cacheKeyFamily(key string) string {
+	if idx := strings.IndexAny(key, ":|"); idx >= 0 {
+		if key[idx] == ':' {
+			return key[:idx+1]
+		}
+		return key[:idx]
+	}
+	return key
+}
+
+// approximateSize оценивает размер записи по длине ключа и JSON-представления значения
+func approximateSize(key string, value interface{}) int64 {
+	size := int64(len(key))
+	if data, err := json.Marshal(value); err == nil {
+		size += int64(len(data))
+	}
+	return size
 }
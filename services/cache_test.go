@@ -0,0 +1,119 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"schedule-api/models"
+)
+
+func TestCacheKeyFamily(t *testing.T) {
+	tests := map[string]string{
+		"universities":                  "universities",
+		"universities|cursor|20":        "universities",
+		"courses:kgu":                   "courses:",
+		"types:kgu:1|cursor|20":         "types:",
+		"files:kgu:1:regular":           "files:",
+		"schedule:kgu:1:regular:a.json": "schedule:",
+	}
+	for key, want := range tests {
+		if got := cacheKeyFamily(key); got != want {
+			t.Errorf("cacheKeyFamily(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	s := NewCacheService(time.Hour, time.Hour)
+
+	s.Get("courses:kgu")
+	s.Set("courses:kgu", []string{"1", "2"}, 0)
+	s.Get("courses:kgu")
+	s.Get("courses:kgu")
+	s.Set("universities", []string{"kgu"}, 0)
+	s.Set("universities|c|20", []string{"kgu"}, 0)
+
+	// Contains не учитывается ни как попадание, ни как промах
+	if !s.Contains("universities") || s.Contains("types:kgu:1") {
+		t.Error("Contains reports wrong presence")
+	}
+
+	stats := s.Stats()
+	courses := stats.Families["courses:"]
+	if courses.Hits != 2 || courses.Misses != 1 || courses.Items != 1 {
+		t.Errorf("courses: = %+v, want 2 hits, 1 miss, 1 item", courses)
+	}
+	if universities := stats.Families["universities"]; universities.Items != 2 || universities.Hits+universities.Misses != 0 {
+		t.Errorf("universities = %+v, want 2 items without lookups", universities)
+	}
+	if stats.ItemCount != 3 || stats.HitRatio != 2.0/3 {
+		t.Errorf("itemCount = %d, hitRatio = %v", stats.ItemCount, stats.HitRatio)
+	}
+
+	// Размер оценивается по ключу и JSON значения: len("courses:kgu") + len(`["1","2"]`)
+	if want := int64(len("courses:kgu") + len(`["1","2"]`)); courses.ApproxBytes != want {
+		t.Errorf("courses: approxBytes = %d, want %d", courses.ApproxBytes, want)
+	}
+	var total int64
+	for _, f := range stats.Families {
+		total += f.ApproxBytes
+	}
+	if stats.ApproxBytes != total {
+		t.Errorf("approxBytes = %d, families sum = %d", stats.ApproxBytes, total)
+	}
+}
+
+func TestCacheRemovals(t *testing.T) {
+	s := NewCacheService(time.Hour, time.Hour)
+
+	s.Set("universities", []string{"kgu"}, 0)
+	s.Set("universities|c|20", []string{"kgu"}, 0)
+	s.Set("courses:kgu", []string{"1"}, 0)
+	s.Set("types:kgu:1", []string{"regular"}, 0)
+	s.Set("files:kgu:1:regular", []string{"a.json"}, 10*time.Millisecond)
+
+	// Истёкшая запись удаляется очисткой go-cache и считается вытеснением
+	time.Sleep(20 * time.Millisecond)
+	s.cache.DeleteExpired()
+
+	s.DeleteList("universities")
+	s.Flush()
+
+	stats := s.Stats()
+	want := map[string]models.CacheFamilyStats{
+		"universities": {Invalidations: 2},
+		"courses:":     {Invalidations: 1},
+		"types:":       {Invalidations: 1},
+		"files:":       {Evictions: 1},
+	}
+	for family, w := range want {
+		if got := stats.Families[family]; got != w {
+			t.Errorf("%s = %+v, want %+v", family, got, w)
+		}
+	}
+	if stats.ItemCount != 0 || stats.ApproxBytes != 0 {
+		t.Errorf("after flush: itemCount = %d, approxBytes = %d", stats.ItemCount, stats.ApproxBytes)
+	}
+	if len(s.sizes) != 0 {
+		t.Errorf("sizes of removed entries are kept: %v", s.sizes)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	s := NewCacheService(time.Hour, time.Hour)
+	s.Set("types:kgu:1", []string{"regular"}, 0)
+	s.Set("courses:kgu", []string{"1"}, 0)
+
+	keys := s.Keys()
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, k.Key)
+		if k.ApproxBytes <= int64(len(k.Key)) || k.ExpiresAt == nil {
+			t.Errorf("%s: approxBytes = %d, expiresAt = %v", k.Key, k.ApproxBytes, k.ExpiresAt)
+		}
+	}
+	if !slices.Equal(names, []string{"courses:kgu", "types:kgu:1"}) {
+		t.Errorf("keys = %v", names)
+	}
+}
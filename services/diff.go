@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"schedule-api/models"
+)
+
+// ErrDiffUnsupported возвращается при сравнении расписаний, отличных от основного
+var ErrDiffUnsupported = errors.New("diff is supported only for regular schedules")
+
+// nullVersion — идентификатор версии объекта в бакете без версионирования
+const nullVersion = "null"
+
+// DiffService строит и хранит смысловые различия между версиями расписаний.
+// Разница каждой обработки сохраняется объектом prefix/<путь JSON>/<версия>.json
+type DiffService struct {
+	minio  *MinIOService
+	bucket string
+	prefix string
+}
+
+func NewDiffService(minio *MinIOService, bucket, prefix string) *DiffService {
+	return &DiffService{
+		minio:  minio,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/") + "/",
+	}
+}
+
+// Store сохраняет разницу, полученную при обработке файла objectPath
+func (s *DiffService) Store(ctx context.Context, objectPath string, diff *models.ScheduleDiff) error {
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("failed to encode diff: %w", err)
+	}
+
+	if _, err := s.minio.UploadFile(ctx, s.bucket, s.objectPath(objectPath, diff.To), bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return fmt.Errorf("failed to store diff: %w", err)
+	}
+	return nil
+}
+
+// Load возвращает сохранённую разницу для версии version файла objectPath или nil, если её нет
+func (s *DiffService) Load(ctx context.Context, objectPath, version string) (*models.ScheduleDiff, error) {
+	diffPath := s.objectPath(objectPath, version)
+	info, err := s.minio.StatObjectInBucket(ctx, s.bucket, diffPath)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	data, err := s.minio.DownloadFile(ctx, s.bucket, diffPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var diff models.ScheduleDiff
+	if err := json.Unmarshal(data, &diff); err != nil {
+		return nil, fmt.Errorf("failed to decode stored diff: %w", err)
+	}
+	return &diff, nil
+}
+
+// Compare сравнивает две версии файла objectPath. Возвращает nil, если одной из версий нет
+func (s *DiffService) Compare(ctx context.Context, objectPath, from, to string) (*models.ScheduleDiff, error) {
+	before, err := s.loadVersion(ctx, objectPath, from)
+	if err != nil || before == nil {
+		return nil, err
+	}
+	after, err := s.loadVersion(ctx, objectPath, to)
+	if err != nil || after == nil {
+		return nil, err
+	}
+
+	diff := DiffRegularSchedules(before, after)
+	diff.From, diff.To = from, to
+	return diff, nil
+}
+
+func (s *DiffService) loadVersion(ctx context.Context, objectPath, version string) (*models.RegularSchedule, error) {
+	data, _, err := s.minio.DownloadVersion(ctx, objectPath, version)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return DecodeRegularSchedule(data)
+}
+
+func (s *DiffService) objectPath(objectPath, version string) string {
+	if version == "" {
+		version = nullVersion
+	}
+	return s.prefix + objectPath + "/" + version + ".json"
+}
+
+// DecodeRegularSchedule разбирает JSON основного расписания.
+// Для замен, экзаменов и содержимого, не являющегося JSON расписания, возвращает ErrDiffUnsupported
+func DecodeRegularSchedule(data []byte) (*models.RegularSchedule, error) {
+	var schedule models.RegularSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode schedule: %w", ErrDiffUnsupported, err)
+	}
+	if schedule.Type != "regular" {
+		return nil, ErrDiffUnsupported
+	}
+	return &schedule, nil
+}
+
+// lessonSlot — место занятия в расписании: группа, день, время и подгруппа.
+// index различает несколько занятий в одном и том же месте
+type lessonSlot struct {
+	group    string
+	day      string
+	time     string
+	subGroup string
+	index    int
+}
+
+type slotLesson struct {
+	order     int
+	date      string
+	dayOfWeek string
+	lesson    models.Lesson
+}
+
+// DiffRegularSchedules сравнивает занятия двух версий по группе, дню и времени.
+// before может быть nil — тогда все занятия after считаются добавленными
+func DiffRegularSchedules(before, after *models.RegularSchedule) *models.ScheduleDiff {
+	oldLessons := indexLessons(before)
+	newLessons := indexLessons(after)
+
+	type orderedChange struct {
+		order  int
+		change models.LessonChange
+	}
+	var changes []orderedChange
+	summary := models.DiffSummary{}
+
+	for slot, next := range newLessons {
+		change := models.LessonChange{
+			Group:     slot.group,
+			Date:      next.date,
+			DayOfWeek: next.dayOfWeek,
+			Time:      slot.time,
+			SubGroup:  slot.subGroup,
+			After:     &next.lesson,
+		}
+		if prev, existed := oldLessons[slot]; existed {
+			change.Fields = lessonFieldChanges(prev.lesson, next.lesson)
+			if len(change.Fields) == 0 {
+				continue
+			}
+			change.Kind = models.LessonModified
+			change.Before = &prev.lesson
+			summary.Modified++
+		} else {
+			change.Kind = models.LessonAdded
+			summary.Added++
+		}
+		changes = append(changes, orderedChange{order: next.order, change: change})
+	}
+
+	for slot, prev := range oldLessons {
+		if _, exists := newLessons[slot]; exists {
+			continue
+		}
+		changes = append(changes, orderedChange{order: prev.order, change: models.LessonChange{
+			Kind:      models.LessonRemoved,
+			Group:     slot.group,
+			Date:      prev.date,
+			DayOfWeek: prev.dayOfWeek,
+			Time:      slot.time,
+			SubGroup:  slot.subGroup,
+			Before:    &prev.lesson,
+		}})
+		summary.Removed++
+	}
+
+	// Порядок изменений — как в таблице: по группе, затем по положению занятия.
+	// Положения старой и новой версии могут совпасть: тогда удалённое занятие идёт первым
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.change.Group != b.change.Group {
+			return a.change.Group < b.change.Group
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		if (a.change.Kind == models.LessonRemoved) != (b.change.Kind == models.LessonRemoved) {
+			return a.change.Kind == models.LessonRemoved
+		}
+		return a.change.SubGroup < b.change.SubGroup
+	})
+
+	diff := &models.ScheduleDiff{
+		GeneratedAt: time.Now().UTC(),
+		Changes:     make([]models.LessonChange, 0, len(changes)),
+	}
+	summary.Groups = make([]string, 0)
+	for _, c := range changes {
+		diff.Changes = append(diff.Changes, c.change)
+		if n := len(summary.Groups); n == 0 || summary.Groups[n-1] != c.change.Group {
+			summary.Groups = append(summary.Groups, c.change.Group)
+		}
+	}
+	diff.Summary = summary
+
+	return diff
+}
+
+// indexLessons раскладывает занятия по местам; order — порядковый номер занятия в таблице
+func indexLessons(schedule *models.RegularSchedule) map[lessonSlot]slotLesson {
+	lessons := make(map[lessonSlot]slotLesson)
+	if schedule == nil {
+		return lessons
+	}
+
+	for _, group := range schedule.Groups {
+		for _, day := range group.Days {
+			for _, lesson := range day.Lessons {
+				slot := lessonSlot{
+					group:    group.GroupNumber,
+					day:      dayKey(day.Date, day.DayOfWeek),
+					time:     normalizeCell(lesson.Time),
+					subGroup: normalizeCell(lesson.SubGroup),
+				}
+				for {
+					if _, taken := lessons[slot]; !taken {
+						break
+					}
+					slot.index++
+				}
+				lessons[slot] = slotLesson{
+					order:     len(lessons),
+					date:      day.Date,
+					dayOfWeek: day.DayOfWeek,
+					lesson:    lesson,
+				}
+			}
+		}
+	}
+	return lessons
+}
+
+// dayKey определяет день: по дате, если она указана, иначе по дню недели
+func dayKey(date, dayOfWeek string) string {
+	if date = normalizeCell(date); date != "" {
+		return date
+	}
+	return strings.ToLower(normalizeCell(dayOfWeek))
+}
+
+// lessonFieldChanges сравнивает значимые для студентов поля занятия
+func lessonFieldChanges(before, after models.Lesson) []models.FieldChange {
+	var fields []models.FieldChange
+	compare := func(name, a, b string) {
+		if normalizeCell(a) != normalizeCell(b) {
+			fields = append(fields, models.FieldChange{Field: name, Before: a, After: b})
+		}
+	}
+	compare("subject", before.Subject, after.Subject)
+	compare("teacher", strings.Join(before.AllTeachers(), ", "), strings.Join(after.AllTeachers(), ", "))
+	compare("classroom", before.Classroom, after.Classroom)
+	compare("type", before.Type, after.Type)
+	return fields
+}
+
+// normalizeCell убирает различия в пробелах, не влияющие на смысл
+func normalizeCell(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"schedule-api/models"
+)
+
+// dayLesson — занятие группы в день недели для сборки тестового расписания
+type dayLesson struct {
+	group string
+	day   string
+	models.Lesson
+}
+
+func regularSchedule(lessons ...dayLesson) *models.RegularSchedule {
+	schedule := &models.RegularSchedule{Type: "regular"}
+	for _, l := range lessons {
+		g := slices.IndexFunc(schedule.Groups, func(g models.GroupSchedule) bool { return g.GroupNumber == l.group })
+		if g < 0 {
+			schedule.Groups = append(schedule.Groups, models.GroupSchedule{GroupNumber: l.group})
+			g = len(schedule.Groups) - 1
+		}
+		group := &schedule.Groups[g]
+
+		d := slices.IndexFunc(group.Days, func(d models.DaySchedule) bool { return d.DayOfWeek == l.day })
+		if d < 0 {
+			group.Days = append(group.Days, models.DaySchedule{DayOfWeek: l.day})
+			d = len(group.Days) - 1
+		}
+		group.Days[d].Lessons = append(group.Days[d].Lessons, l.Lesson)
+	}
+	return schedule
+}
+
+// describeChanges записывает изменения как "kind group time subGroup [поля]"
+func describeChanges(diff *models.ScheduleDiff) []string {
+	result := make([]string, 0, len(diff.Changes))
+	for _, c := range diff.Changes {
+		fields := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			fields = append(fields, fmt.Sprintf("%s:%s→%s", f.Field, f.Before, f.After))
+		}
+		result = append(result, strings.TrimSpace(fmt.Sprintf("%s %s %s %s %s", c.Kind, c.Group, c.Time, c.SubGroup, strings.Join(fields, " "))))
+	}
+	return result
+}
+
+func TestDiffRegularSchedules(t *testing.T) {
+	math := dayLesson{"23101", "Понедельник", models.Lesson{Time: "8.30-10.00", Subject: "Математика", Teacher: "Гареева Г.А.", Classroom: "305", Type: "лек."}}
+	physics := dayLesson{"23101", "Понедельник", models.Lesson{Time: "10.10-11.40", Subject: "Физика", Teacher: "Иванов И.И.", Classroom: "210", Type: "пр."}}
+	history := dayLesson{"23102", "Вторник", models.Lesson{Time: "8.30-10.00", Subject: "История", Teacher: "Петров П.П.", Classroom: "101", Type: "лек."}}
+
+	with := func(l dayLesson, change func(*models.Lesson)) dayLesson {
+		change(&l.Lesson)
+		return l
+	}
+
+	tests := []struct {
+		name       string
+		before     *models.RegularSchedule
+		after      *models.RegularSchedule
+		want       []string
+		wantGroups []string
+	}{
+		{
+			name:       "identical versions",
+			before:     regularSchedule(math, physics, history),
+			after:      regularSchedule(math, physics, history),
+			want:       []string{},
+			wantGroups: []string{},
+		},
+		{
+			name:       "whitespace is not a change",
+			before:     regularSchedule(math),
+			after:      regularSchedule(with(math, func(l *models.Lesson) { l.Subject = " Математика  " })),
+			want:       []string{},
+			wantGroups: []string{},
+		},
+		{
+			name:       "first upload adds everything",
+			after:      regularSchedule(math, history),
+			want:       []string{"added 23101 8.30-10.00", "added 23102 8.30-10.00"},
+			wantGroups: []string{"23101", "23102"},
+		},
+		{
+			name:       "classroom change",
+			before:     regularSchedule(math, physics),
+			after:      regularSchedule(math, with(physics, func(l *models.Lesson) { l.Classroom = "305" })),
+			want:       []string{"modified 23101 10.10-11.40  classroom:210→305"},
+			wantGroups: []string{"23101"},
+		},
+		{
+			name:   "second teacher joins a lesson",
+			before: regularSchedule(math),
+			after: regularSchedule(with(math, func(l *models.Lesson) {
+				l.Teachers = []string{"Гареева Г.А.", "Сидоров С.С."}
+			})),
+			want:       []string{"modified 23101 8.30-10.00  teacher:Гареева Г.А.→Гареева Г.А., Сидоров С.С."},
+			wantGroups: []string{"23101"},
+		},
+		{
+			name:       "lessons added and removed in different groups",
+			before:     regularSchedule(math, history),
+			after:      regularSchedule(math, physics),
+			want:       []string{"added 23101 10.10-11.40", "removed 23102 8.30-10.00"},
+			wantGroups: []string{"23101", "23102"},
+		},
+		{
+			name:   "lesson split into subgroups",
+			before: regularSchedule(physics),
+			after: regularSchedule(
+				with(physics, func(l *models.Lesson) { l.SubGroup = "1п/г" }),
+				with(physics, func(l *models.Lesson) { l.SubGroup = "2п/г"; l.Teacher = "Петров П.П." }),
+			),
+			want:       []string{"removed 23101 10.10-11.40", "added 23101 10.10-11.40 1п/г", "added 23101 10.10-11.40 2п/г"},
+			wantGroups: []string{"23101"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffRegularSchedules(tt.before, tt.after)
+
+			if got := describeChanges(diff); !slices.Equal(got, tt.want) {
+				t.Errorf("changes\n got: %q\nwant: %q", got, tt.want)
+			}
+			if !slices.Equal(diff.Summary.Groups, tt.wantGroups) {
+				t.Errorf("summary groups = %v, want %v", diff.Summary.Groups, tt.wantGroups)
+			}
+
+			var added, removed, modified int
+			for _, c := range diff.Changes {
+				switch c.Kind {
+				case models.LessonAdded:
+					added++
+				case models.LessonRemoved:
+					removed++
+				case models.LessonModified:
+					modified++
+				}
+			}
+			if s := diff.Summary; s.Added != added || s.Removed != removed || s.Modified != modified {
+				t.Errorf("summary = %+v, want added %d, removed %d, modified %d", s, added, removed, modified)
+			}
+			if diff.Summary.Empty() != (len(tt.want) == 0) {
+				t.Errorf("Summary.Empty() = %v with %d changes", diff.Summary.Empty(), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"schedule-api/models"
+)
+
+// subscriberBuffer — сколько событий может ждать медленный подписчик, прежде чем его отключат
+const subscriberBuffer = 64
+
+// EventBus раздаёт события об изменениях расписаний подписчикам (SSE, WebSocket)
+// и хранит ограниченный журнал последних событий для возобновления по Last-Event-ID.
+// Идентификатор события — "<эпоха запуска>-<номер>", чтобы после перезапуска
+// клиент не принял новые события за уже полученные
+type EventBus struct {
+	epoch int64
+	size  int
+
+	mu          sync.Mutex
+	seq         uint64
+	log         []models.Event // От старых к новым, не больше size
+	subscribers map[*EventSubscription]struct{}
+	closed      bool
+}
+
+// EventSubscription — подписка на события. Канал C закрывается при отписке, остановке шины
+// или если подписчик не успевает читать события
+type EventSubscription struct {
+	C      <-chan models.Event
+	ch     chan models.Event
+	filter models.EventFilter
+}
+
+func NewEventBus(size int) *EventBus {
+	return &EventBus{
+		epoch:       time.Now().Unix(),
+		size:        size,
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Publish присваивает событию идентификатор и время и рассылает его подписчикам
+func (b *EventBus) Publish(event models.Event) models.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event.ID = fmt.Sprintf("%d-%d", b.epoch, b.seq)
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.log = append(b.log, event)
+	if len(b.log) > b.size {
+		b.log = b.log[len(b.log)-b.size:]
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Подписчик отстал: отключаем, он переподключится с Last-Event-ID
+			b.remove(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe регистрирует подписчика. Если передан lastEventID, возвращает пропущенные
+// события после него; resumed == false означает, что часть событий уже вытеснена из журнала
+// (или сервис перезапускался) и клиенту нужно перечитать данные целиком
+func (b *EventBus) Subscribe(filter models.EventFilter, lastEventID string) (sub *EventSubscription, missed []models.Event, resumed bool) {
+	ch := make(chan models.Event, subscriberBuffer)
+	sub = &EventSubscription{C: ch, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return sub, nil, true
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	seq, ok := b.parseID(lastEventID)
+	if !ok || seq > b.seq {
+		return sub, nil, false
+	}
+	// Продолжить можно, если журнал ещё содержит событие, следующее за lastEventID
+	resumed = seq == b.seq || (len(b.log) > 0 && b.logSeq(0) <= seq+1)
+
+	for i, event := range b.log {
+		if b.logSeq(i) > seq && filter.Matches(event) {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, resumed
+}
+
+// Unsubscribe отключает подписчика; повторные вызовы безопасны
+func (b *EventBus) Unsubscribe(sub *EventSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// Close отключает всех подписчиков — вызывается при остановке сервиса,
+// чтобы долгоживущие соединения не задерживали завершение
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// remove вызывается под b.mu
+func (b *EventBus) remove(sub *EventSubscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// logSeq возвращает номер i-го события журнала; вызывается под b.mu
+func (b *EventBus) logSeq(i int) uint64 {
+	return b.seq - uint64(len(b.log)-1-i)
+}
+
+// parseID разбирает идентификатор события; события другой эпохи не считаются своими
+func (b *EventBus) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != strconv.FormatInt(b.epoch, 10) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"schedule-api/models"
+)
+
+// ErrNotExamSchedule возвращается, если файл не является экзаменационным расписанием
+var ErrNotExamSchedule = errors.New("not an exam schedule")
+
+// DecodeExamSchedule разбирает JSON экзаменационного расписания. ErrNotExamSchedule возвращается
+// только для файлов другого типа; повреждённый JSON — отдельная ошибка разбора
+func DecodeExamSchedule(data []byte) (*models.ExamSchedule, error) {
+	// Тип проверяется до полного разбора: у основного расписания и замен другая структура полей
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule: %w", err)
+	}
+	if header.Type != "exams" {
+		return nil, ErrNotExamSchedule
+	}
+
+	var schedule models.ExamSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to decode exam schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// ExamGroups возвращает группы расписания. Файлы, сохранённые без списка групп,
+// восстанавливают его по группам мероприятий
+func ExamGroups(schedule *models.ExamSchedule) []string {
+	if len(schedule.Groups) > 0 {
+		return schedule.Groups
+	}
+	groups := make([]string, 0)
+	for _, exam := range schedule.Exams {
+		if exam.Group != "" && !slices.Contains(groups, exam.Group) {
+			groups = append(groups, exam.Group)
+		}
+	}
+	return groups
+}
+
+// GroupExamSession возвращает мероприятия группы по дате и времени начала.
+// Возвращает nil, если группы нет в расписании
+func GroupExamSession(schedule *models.ExamSchedule, group string) *models.ExamSession {
+	if !slices.Contains(ExamGroups(schedule), group) {
+		return nil
+	}
+
+	session := &models.ExamSession{
+		Group: group,
+		Exams: make([]models.Exam, 0),
+	}
+	for _, exam := range schedule.Exams {
+		if exam.Group == group {
+			session.Exams = append(session.Exams, exam)
+		}
+	}
+
+	// Неразобранные даты (не YYYY-MM-DD) уходят в конец, сохраняя порядок файла
+	sort.SliceStable(session.Exams, func(i, j int) bool {
+		a, b := session.Exams[i], session.Exams[j]
+		aParsed, bParsed := isISODate(a.Date), isISODate(b.Date)
+		if aParsed != bParsed {
+			return aParsed
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return startMinutes(a.Time) < startMinutes(b.Time)
+	})
+
+	for _, exam := range session.Exams {
+		if !isISODate(exam.Date) {
+			continue
+		}
+		end := exam.Date
+		if exam.EndDate != "" {
+			end = exam.EndDate
+		}
+		if session.StartDate == "" {
+			session.StartDate = exam.Date
+		}
+		if end > session.EndDate {
+			session.EndDate = end
+		}
+	}
+	return session
+}
+
+var startTimePattern = regexp.MustCompile(`(\d{1,2})[.:](\d{2})`)
+
+// startMinutes возвращает время начала ("9.00-10.30", "14:00") в минутах от полуночи;
+// без времени мероприятие идёт в конец дня
+func startMinutes(value string) int {
+	m := startTimePattern.FindStringSubmatch(value)
+	if m == nil {
+		return 24 * 60
+	}
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	return hours*60 + minutes
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"schedule-api/models"
+)
+
+func TestGroupExamSession(t *testing.T) {
+	exams := []models.Exam{
+		{Date: "2026-01-15", Time: "9.00", Group: "23101", Subject: "Физика"},
+		{Date: "2026-01-12", Time: "13.00", Group: "23101", Subject: "Химия"},
+		{Date: "2026-01-12", Time: "9.00", Group: "23101", Subject: "Математика"},
+		{Date: "2026-01-12", Time: "9.00", Group: "23102", Subject: "История"},
+		{Date: "по согласованию", Group: "23101", Subject: "Практика"},
+		{Date: "2026-01-16", EndDate: "2026-01-18", Group: "23101", Subject: "Курсовая работа"},
+	}
+
+	tests := []struct {
+		name         string
+		schedule     models.ExamSchedule
+		group        string
+		wantSubjects []string // nil — группы нет в расписании
+		wantStart    string
+		wantEnd      string
+	}{
+		{
+			name:         "sorted by date and start time",
+			schedule:     models.ExamSchedule{Groups: []string{"23101", "23102"}, Exams: exams},
+			group:        "23101",
+			wantSubjects: []string{"Математика", "Химия", "Физика", "Курсовая работа", "Практика"},
+			wantStart:    "2026-01-12",
+			wantEnd:      "2026-01-18",
+		},
+		{
+			name:         "groups taken from exams when the list is missing",
+			schedule:     models.ExamSchedule{Exams: exams},
+			group:        "23102",
+			wantSubjects: []string{"История"},
+			wantStart:    "2026-01-12",
+			wantEnd:      "2026-01-12",
+		},
+		{
+			name:         "group without exams",
+			schedule:     models.ExamSchedule{Groups: []string{"23101", "23103"}, Exams: exams},
+			group:        "23103",
+			wantSubjects: []string{},
+		},
+		{
+			name:     "unknown group",
+			schedule: models.ExamSchedule{Exams: exams},
+			group:    "23109",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := GroupExamSession(&tt.schedule, tt.group)
+			if tt.wantSubjects == nil {
+				if session != nil {
+					t.Fatalf("got session %+v, want nil", session)
+				}
+				return
+			}
+			if session == nil {
+				t.Fatal("got nil session")
+			}
+
+			subjects := make([]string, 0, len(session.Exams))
+			for _, exam := range session.Exams {
+				subjects = append(subjects, exam.Subject)
+			}
+			if !slices.Equal(subjects, tt.wantSubjects) {
+				t.Errorf("exams = %v, want %v", subjects, tt.wantSubjects)
+			}
+			if session.StartDate != tt.wantStart || session.EndDate != tt.wantEnd {
+				t.Errorf("session = %s..%s, want %s..%s", session.StartDate, session.EndDate, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestDecodeExamSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantNotExam bool // Ожидается ErrNotExamSchedule
+		wantErr     bool // Ожидается другая ошибка разбора
+	}{
+		{name: "exam schedule", data: `{"type":"exams","groups":["23101"],"exams":[{"group":"23101","subject":"Физика"}]}`},
+		{name: "regular schedule", data: `{"type":"regular","groups":[{"groupNumber":"23101","days":[]}]}`, wantNotExam: true},
+		{name: "replacements", data: `{"type":"replacements","groups":["23101"]}`, wantNotExam: true},
+		{name: "without type", data: `{"exams":[]}`, wantNotExam: true},
+		{name: "not json", data: `<xml/>`, wantErr: true},
+		{name: "truncated json", data: `{"type":"exams","exams":[`, wantErr: true},
+		{name: "exam schedule with a wrong field type", data: `{"type":"exams","groups":[{"groupNumber":"23101"}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := DecodeExamSchedule([]byte(tt.data))
+			if got := errors.Is(err, ErrNotExamSchedule); got != tt.wantNotExam {
+				t.Errorf("errors.Is(%v, ErrNotExamSchedule) = %v, want %v", err, got, tt.wantNotExam)
+			}
+			if got := err != nil && !tt.wantNotExam; got != tt.wantErr {
+				t.Errorf("err = %v, want decode error: %v", err, tt.wantErr)
+			}
+			if err == nil && (schedule == nil || len(schedule.Exams) != 1) {
+				t.Errorf("schedule = %+v", schedule)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JobTracker учитывает выполняющиеся задания обработки файлов,
+// чтобы при остановке дождаться их завершения или сообщить, что было прервано
+type JobTracker struct {
+	mu     sync.Mutex
+	jobs   map[uint64]*Job
+	nextID atomic.Uint64
+	wg     sync.WaitGroup
+}
+
+func NewJobTracker() *JobTracker {
+	return &JobTracker{
+		jobs: make(map[uint64]*Job),
+	}
+}
+
+// Job — задание обработки пакета файлов
+type Job struct {
+	tracker *JobTracker
+	id      uint64
+
+	mu        sync.Mutex
+	info      JobInfo
+	completed bool
+}
+
+// JobInfo — снимок состояния задания
+type JobInfo struct {
+	ID          uint64    `json:"id"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Actor       string    `json:"actor,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+	Total       int       `json:"total"`
+	Processed   int       `json:"processed"`
+	CurrentFile string    `json:"currentFile,omitempty"`
+}
+
+// Start регистрирует новое задание из total файлов
+func (t *JobTracker) Start(requestID, actor string, total int) *Job {
+	job := &Job{
+		tracker: t,
+		id:      t.nextID.Add(1),
+	}
+	job.info = JobInfo{
+		ID:        job.id,
+		RequestID: requestID,
+		Actor:     actor,
+		StartedAt: time.Now(),
+		Total:     total,
+	}
+
+	t.mu.Lock()
+	t.jobs[job.id] = job
+	t.mu.Unlock()
+	t.wg.Add(1)
+
+	return job
+}
+
+// Active возвращает снимки незавершённых заданий в порядке запуска
+func (t *JobTracker) Active() []JobInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := make([]JobInfo, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		job.mu.Lock()
+		active = append(active, job.info)
+		job.mu.Unlock()
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active
+}
+
+// Wait ждёт завершения всех заданий или истечения timeout. Возвращает false, если задания остались
+func (t *JobTracker) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Processing отмечает файл, который обрабатывается сейчас
+func (j *Job) Processing(file string) {
+	j.mu.Lock()
+	j.info.CurrentFile = file
+	j.mu.Unlock()
+}
+
+// FileDone отмечает завершение обработки текущего файла
+func (j *Job) FileDone() {
+	j.mu.Lock()
+	j.info.Processed++
+	j.info.CurrentFile = ""
+	j.mu.Unlock()
+}
+
+// Finish снимает задание с учёта; повторные вызовы игнорируются
+func (j *Job) Finish() {
+	j.mu.Lock()
+	if j.completed {
+		j.mu.Unlock()
+		return
+	}
+	j.completed = true
+	j.mu.Unlock()
+
+	j.tracker.mu.Lock()
+	delete(j.tracker.jobs, j.id)
+	j.tracker.mu.Unlock()
+	j.tracker.wg.Done()
+}
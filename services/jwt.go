@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksRefreshInterval = 15 * time.Minute
+	jwksMinRefetch      = 30 * time.Second
+)
+
+// JWTVerifier проверяет bearer-токены университетского SSO по набору ключей JWKS
+type JWTVerifier struct {
+	issuer            string
+	audience          string
+	roleClaim         string
+	universitiesClaim string
+	roleMapping       map[string]models.Role
+	keys              *jwksCache
+}
+
+// NewJWTVerifier создаёт верификатор по конфигурации. Если JWKS не задан, возвращает nil
+func NewJWTVerifier(cfg *config.Config) (*JWTVerifier, error) {
+	if cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		return nil, nil
+	}
+
+	roleMapping, err := parseRoleMapping(cfg.JWTRoleMap)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &JWTVerifier{
+		issuer:            cfg.JWTIssuer,
+		audience:          cfg.JWTAudience,
+		roleClaim:         cfg.JWTRoleClaim,
+		universitiesClaim: cfg.JWTUniversitiesClaim,
+		roleMapping:       roleMapping,
+		keys:              newJWKSCache(cfg.JWKSFile, cfg.JWKSURL),
+	}
+
+	// Загружаем ключи сразу, чтобы ошибка конфигурации была видна при старте
+	if err := v.keys.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify проверяет подпись и стандартные claims токена и возвращает клиента
+func (v *JWTVerifier) Verify(ctx context.Context, tokenString string) (*models.Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.get(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims.GetSubject()
+	if username, ok := claims["preferred_username"].(string); ok && username != "" {
+		subject = username
+	}
+
+	// Пустой список университетов у клиента означает доступ ко всем, поэтому токен
+	// без claim университетов принимается только у администратора
+	role := v.mapRole(claimStrings(claims, v.roleClaim))
+	universities := claimStrings(claims, v.universitiesClaim)
+	if len(universities) == 0 && role != models.RoleAdmin {
+		return nil, fmt.Errorf("token grants no universities: claim %q is missing or empty", v.universitiesClaim)
+	}
+
+	return &models.Principal{
+		Subject:      subject,
+		Role:         role,
+		Universities: universities,
+		AuthMethod:   "jwt",
+	}, nil
+}
+
+// mapRole выбирает наибольшую роль среди значений claim. Если задан JWT_ROLE_MAP, учитываются
+// только перечисленные в нём значения, иначе значения claim сравниваются с именами ролей.
+// Токен без подходящих значений получает роль reader
+func (v *JWTVerifier) mapRole(values []string) models.Role {
+	role := models.RoleReader
+	for _, value := range values {
+		mapped, ok := v.roleMapping[value]
+		if !ok {
+			if len(v.roleMapping) > 0 {
+				continue
+			}
+			mapped = models.Role(value)
+		}
+		if mapped.Valid() && mapped.Includes(role) {
+			role = mapped
+		}
+	}
+	return role
+}
+
+// parseRoleMapping разбирает строку вида "schedule-admins=admin,dean-office=uploader"
+func parseRoleMapping(raw string) (map[string]models.Role, error) {
+	mapping := make(map[string]models.Role)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		claim, role, found := strings.Cut(pair, "=")
+		if !found || !models.Role(role).Valid() {
+			return nil, fmt.Errorf("invalid JWT role mapping %q", pair)
+		}
+		mapping[strings.TrimSpace(claim)] = models.Role(strings.TrimSpace(role))
+	}
+	return mapping, nil
+}
+
+// claimStrings читает claim по пути через точку (например, realm_access.roles) как список строк
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(v, ",", " "))
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// jwksCache хранит открытые ключи из JWKS-файла или URL и периодически их обновляет
+type jwksCache struct {
+	file   string
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time    // Время последней успешной загрузки
+	nextFetch time.Time    // Раньше этого времени ключи не перечитываются, в том числе после ошибки
+	failures  int          // Неудачные загрузки подряд
+	inflight  *jwksRefresh // Загрузка, которую ждут все запросы
+}
+
+// jwksRefresh — загрузка ключей, выполняемая одна на все одновременные запросы
+type jwksRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+func newJWKSCache(file, url string) *jwksCache {
+	return &jwksCache{
+		file:   file,
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+func (c *jwksCache) get(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.lookup(kid)
+	now := c.now()
+	stale := now.Sub(c.fetchedAt) > jwksRefreshInterval
+	canRefetch := !now.Before(c.nextFetch)
+	c.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	// Неизвестный kid может означать ротацию ключей у провайдера. Пока действует пауза
+	// после предыдущей загрузки, используются уже загруженные ключи
+	if canRefetch {
+		if err := c.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+		c.mu.RLock()
+		key, ok = c.lookup(kid)
+		c.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup ищет ключ по kid; токен без kid допустим, если в наборе ровно один ключ
+func (c *jwksCache) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// refresh перечитывает ключи. Одновременные вызовы ждут одну загрузку; она не прерывается
+// отменой запроса, который её начал, и ограничена тайм-аутом HTTP-клиента
+func (c *jwksCache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	r := c.inflight
+	if r == nil {
+		r = &jwksRefresh{done: make(chan struct{})}
+		c.inflight = r
+		go c.load(context.WithoutCancel(ctx), r)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *jwksCache) load(ctx context.Context, r *jwksRefresh) {
+	keys, err := c.fetchKeys(ctx)
+
+	c.mu.Lock()
+	now := c.now()
+	if err != nil {
+		c.failures++
+	} else {
+		c.keys = keys
+		c.fetchedAt = now
+		c.failures = 0
+	}
+	c.nextFetch = now.Add(jwksBackoff(c.failures))
+	c.inflight = nil
+	c.mu.Unlock()
+
+	r.err = err
+	close(r.done)
+}
+
+func (c *jwksCache) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// jwksBackoff — пауза перед следующей загрузкой после failures неудачных попыток подряд:
+// jwksMinRefetch, удваиваемая с каждой ошибкой, но не дольше jwksRefreshInterval
+func jwksBackoff(failures int) time.Duration {
+	backoff := jwksMinRefetch
+	for i := 1; i < failures && backoff < jwksRefreshInterval; i++ {
+		backoff *= 2
+	}
+	return min(backoff, jwksRefreshInterval)
+}
+
+func (c *jwksCache) fetch(ctx context.Context) ([]byte, error) {
+	if c.file != "" {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwks file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks url: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
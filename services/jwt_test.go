@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testJWKS возвращает JWKS с открытым ключом key под идентификатором kid
+func testJWKS(t *testing.T, kid string, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	data, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": kid,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   encode(key.N),
+			"e":   encode(big.NewInt(int64(key.E))),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWTVerify(t *testing.T) {
+	key := newTestRSAKey(t)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, testJWKS(t, "sso-1", key), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewJWTVerifier(&config.Config{
+		JWKSFile:             jwksFile,
+		JWTIssuer:            "https://sso.example.edu",
+		JWTAudience:          "schedule-api",
+		JWTRoleClaim:         "realm_access.roles",
+		JWTUniversitiesClaim: "universities",
+		JWTRoleMap:           "schedule-admins=admin,dean-office=uploader",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// claims возвращает корректные claims, заменяя и удаляя (значение nil) указанные
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":          "8f1c",
+			"iss":          "https://sso.example.edu",
+			"aud":          "schedule-api",
+			"exp":          time.Now().Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{"roles": []string{"dean-office"}},
+			"universities": []string{"kgu"},
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(c, name)
+			} else {
+				c[name] = value
+			}
+		}
+		return c
+	}
+
+	tests := []struct {
+		name             string
+		kid              string
+		claims           jwt.MapClaims
+		wantErr          string // Подстрока ошибки; пусто — токен принимается
+		wantSubject      string
+		wantRole         models.Role
+		wantUniversities []string
+	}{
+		{name: "valid", kid: "sso-1", claims: claims(nil), wantSubject: "8f1c", wantRole: models.RoleUploader, wantUniversities: []string{"kgu"}},
+		{name: "preferred username", kid: "sso-1", claims: claims(jwt.MapClaims{"preferred_username": "ivanov"}), wantSubject: "ivanov", wantRole: models.RoleUploader, wantUniversities: []string{"kgu"}},
+		{name: "token without kid and a single key", claims: claims(nil), wantSubject: "8f1c", wantRole: models.RoleUploader, wantUniversities: []string{"kgu"}},
+		{name: "expired", kid: "sso-1", claims: claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), wantErr: "expired"},
+		{name: "without expiration", kid: "sso-1", claims: claims(jwt.MapClaims{"exp": nil}), wantErr: "exp"},
+		{name: "wrong issuer", kid: "sso-1", claims: claims(jwt.MapClaims{"iss": "https://evil.example.com"}), wantErr: "issuer"},
+		{name: "wrong audience", kid: "sso-1", claims: claims(jwt.MapClaims{"aud": "mail"}), wantErr: "audience"},
+		{name: "unknown kid", kid: "sso-2", claims: claims(nil), wantErr: `unknown signing key "sso-2"`},
+		{name: "highest mapped role wins", kid: "sso-1", claims: claims(jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"dean-office", "schedule-admins"}}}), wantSubject: "8f1c", wantRole: models.RoleAdmin, wantUniversities: []string{"kgu"}},
+		{name: "unmapped role name is ignored", kid: "sso-1", claims: claims(jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"admin"}}}), wantSubject: "8f1c", wantRole: models.RoleReader, wantUniversities: []string{"kgu"}},
+		{name: "universities as a string", kid: "sso-1", claims: claims(jwt.MapClaims{"universities": "kgu, agtu"}), wantSubject: "8f1c", wantRole: models.RoleUploader, wantUniversities: []string{"kgu", "agtu"}},
+		{name: "no universities for an uploader", kid: "sso-1", claims: claims(jwt.MapClaims{"universities": nil}), wantErr: "grants no universities"},
+		{name: "no universities for an admin", kid: "sso-1", claims: claims(jwt.MapClaims{"universities": nil, "realm_access": map[string]interface{}{"roles": []string{"schedule-admins"}}}), wantSubject: "8f1c", wantRole: models.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := v.Verify(t.Context(), signTestToken(t, key, tt.kid, tt.claims))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Subject != tt.wantSubject || principal.Role != tt.wantRole || principal.AuthMethod != "jwt" ||
+				!slices.Equal(principal.Universities, tt.wantUniversities) {
+				t.Errorf("principal = %+v, want %s %s %v", principal, tt.wantSubject, tt.wantRole, tt.wantUniversities)
+			}
+		})
+	}
+
+	// Подпись чужим ключом с известным kid не принимается
+	if _, err := v.Verify(t.Context(), signTestToken(t, newTestRSAKey(t), "sso-1", claims(nil))); err == nil {
+		t.Error("token signed by another key was accepted")
+	}
+}
+
+func TestJWTRoleNamesWithoutMapping(t *testing.T) {
+	v := &JWTVerifier{}
+	tests := []struct {
+		values []string
+		want   models.Role
+	}{
+		{values: nil, want: models.RoleReader},
+		{values: []string{"uploader", "student"}, want: models.RoleUploader},
+		{values: []string{"admin", "reader"}, want: models.RoleAdmin},
+	}
+	for _, tt := range tests {
+		if got := v.mapRole(tt.values); got != tt.want {
+			t.Errorf("mapRole(%v) = %s, want %s", tt.values, got, tt.want)
+		}
+	}
+
+	if _, err := parseRoleMapping("schedule-admins=owner"); err == nil {
+		t.Error("mapping to an unknown role was accepted")
+	}
+}
+
+// jwksServer отдаёт JWKS и считает запросы; пока fail выставлен, отвечает ошибкой
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	fail     atomic.Bool
+}
+
+func newJWKSServer(t *testing.T, jwks []byte, handle func()) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if handle != nil {
+			handle()
+		}
+		if s.fail.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(jwks)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestJWKSRefreshBackoff(t *testing.T) {
+	server := newJWKSServer(t, testJWKS(t, "sso-1", newTestRSAKey(t)), nil)
+
+	now := time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)
+	c := newJWKSCache("", server.URL)
+	c.now = func() time.Time { return now }
+	if err := c.refresh(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Неизвестный kid сразу после загрузки не вызывает повторного запроса
+	if _, err := c.get(t.Context(), "sso-2"); err == nil || server.requests.Load() != 1 {
+		t.Fatalf("unknown kid right after refresh: err = %v, requests = %d", err, server.requests.Load())
+	}
+
+	// Провайдер недоступен: неудачная попытка откладывает следующую
+	server.fail.Store(true)
+	now = now.Add(jwksMinRefetch + time.Second)
+	if _, err := c.get(t.Context(), "sso-2"); err == nil || !strings.Contains(err.Error(), "unexpected status 502") {
+		t.Fatalf("failed refresh: err = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.get(t.Context(), "sso-2"); err == nil {
+			t.Fatal("unknown kid accepted")
+		}
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Fatalf("requests during backoff = %d, want 2", got)
+	}
+
+	// Ключи, загруженные ранее, продолжают работать и после истечения срока обновления
+	now = now.Add(jwksRefreshInterval)
+	if _, err := c.get(t.Context(), "sso-1"); err != nil {
+		t.Fatalf("stale key after failed refresh: %v", err)
+	}
+	if _, err := c.get(t.Context(), "sso-1"); err != nil || server.requests.Load() != 3 {
+		t.Fatalf("stale key during backoff: err = %v, requests = %d", err, server.requests.Load())
+	}
+
+	// Пауза растёт с каждой ошибкой подряд
+	now = now.Add(jwksMinRefetch + time.Second)
+	if _, err := c.get(t.Context(), "sso-2"); err == nil || server.requests.Load() != 3 {
+		t.Fatalf("second failure did not extend backoff: requests = %d", server.requests.Load())
+	}
+	now = now.Add(jwksMinRefetch * 4)
+	server.fail.Store(false)
+	if _, err := c.get(t.Context(), "sso-2"); err == nil || server.requests.Load() != 4 {
+		t.Fatalf("refresh after backoff: requests = %d", server.requests.Load())
+	}
+	if c.failures != 0 {
+		t.Errorf("failures after a successful refresh = %d", c.failures)
+	}
+
+	for failures, want := range map[int]time.Duration{0: jwksMinRefetch, 1: jwksMinRefetch, 2: 2 * jwksMinRefetch, 3: 4 * jwksMinRefetch, 100: jwksRefreshInterval} {
+		if got := jwksBackoff(failures); got != want {
+			t.Errorf("jwksBackoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestJWKSRefreshSingleFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := newJWKSServer(t, testJWKS(t, "sso-1", newTestRSAKey(t)), func() { <-release })
+	c := newJWKSCache("", server.URL)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.get(t.Context(), "sso-1")
+			errs <- err
+		}()
+	}
+
+	// Отменённый запрос перестаёт ждать, но общая загрузка продолжается
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := c.get(ctx, "sso-1"); err != context.Canceled {
+		t.Errorf("cancelled caller: err = %v, want context.Canceled", err)
+	}
+
+	for server.requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent get: %v", err)
+		}
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
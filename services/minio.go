@@ -2,24 +2,33 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"net"
 	"net/url"
+	"path"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"schedule-api/config"
+	"schedule-api/logging"
+	"schedule-api/metrics"
 	"schedule-api/models"
+	"schedule-api/tracing"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MinIOService struct {
 	client *minio.Client
-	bucket string
-	urlTTL time.Duration
+	bucket string       // Бакет обработанных расписаний (TARGET_BUCKET): в него пишет обработчик, из него читает API
+	urlTTL atomic.Int64 // Время жизни presigned URL в наносекундах, меняется при перезагрузке конфигурации
 }
 
 func NewMinIOService(cfg *config.Config) (*MinIOService, error) {
@@ -31,17 +40,24 @@ func NewMinIOService(cfg *config.Config) (*MinIOService, error) {
 		return nil, fmt.Errorf("failed to create minio client: %w", err)
 	}
 
-	return &MinIOService{
+	s := &MinIOService{
 		client: client,
-		bucket: cfg.MinIOBucket,
-		urlTTL: cfg.PresignedURLTTL,
-	}, nil
+		bucket: cfg.TargetBucket,
+	}
+	s.urlTTL.Store(int64(cfg.PresignedURLTTL))
+	return s, nil
+}
+
+// SetPresignedURLTTL меняет время жизни новых presigned URL
+func (s *MinIOService) SetPresignedURLTTL(ttl time.Duration) {
+	s.urlTTL.Store(int64(ttl))
 }
 
 // ListPrefixes возвращает список "папок" на указанном уровне
-func (s *MinIOService) ListPrefixes(ctx context.Context, prefix string) ([]string, error) {
-	log.Println("MinIOService - ListPrefixes")
-	var prefixes []string
+func (s *MinIOService) ListPrefixes(ctx context.Context, prefix string) (prefixes []string, err error) {
+	ctx, end := startStorageOperation(ctx, "ListPrefixes", s.bucket, prefix)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("listing prefixes", "bucket", s.bucket, "prefix", prefix)
 
 	opts := minio.ListObjectsOptions{
 		Prefix:    prefix,
@@ -67,9 +83,53 @@ func (s *MinIOService) ListPrefixes(ctx context.Context, prefix string) ([]strin
 	return prefixes, nil
 }
 
+// ListPrefixesPage возвращает до limit имён каталогов, следующих за after, и признак,
+// что за ними есть ещё. Чтение начинается с after (StartAfter) и прекращается, как только страница собрана
+func (s *MinIOService) ListPrefixesPage(ctx context.Context, prefix, after string, limit int) (prefixes []string, more bool, err error) {
+	ctx, end := startStorageOperation(ctx, "ListPrefixes", s.bucket, prefix)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("listing prefixes page", "bucket", s.bucket, "prefix", prefix, "after", after, "limit", limit)
+
+	// Отмена останавливает листинг, когда страница собрана
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: false,
+	}
+	startAfter := ""
+	if after != "" {
+		startAfter = prefix + after + "/"
+		opts.StartAfter = startAfter
+	}
+
+	for object := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if object.Err != nil {
+			return nil, false, object.Err
+		}
+		// Каталог из StartAfter может вернуться снова, если в нём есть объекты
+		if !strings.HasSuffix(object.Key, "/") || object.Key <= startAfter {
+			continue
+		}
+		name := path.Base(object.Key)
+		if name == "" || contains(prefixes, name) {
+			continue
+		}
+		if len(prefixes) == limit {
+			return prefixes, true, nil
+		}
+		prefixes = append(prefixes, name)
+	}
+
+	return prefixes, false, nil
+}
+
 // ListFiles возвращает список файлов в указанном префиксе
-func (s *MinIOService) ListFiles(ctx context.Context, prefix string) ([]models.ScheduleFile, error) {
-	var files []models.ScheduleFile
+func (s *MinIOService) ListFiles(ctx context.Context, prefix string) (files []models.ScheduleFile, err error) {
+	ctx, end := startStorageOperation(ctx, "ListFiles", s.bucket, prefix)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("listing files", "bucket", s.bucket, "prefix", prefix)
 
 	opts := minio.ListObjectsOptions{
 		Prefix:       prefix,
@@ -87,8 +147,13 @@ func (s *MinIOService) ListFiles(ctx context.Context, prefix string) ([]models.S
 			continue
 		}
 
-		// Проверяем что файл xlsx
-		if !strings.HasSuffix(strings.ToLower(object.Key), ".xlsx") {
+		// В версионируемом бакете показываем только текущую версию; удалённые файлы пропускаем
+		if !object.IsLatest || object.IsDeleteMarker {
+			continue
+		}
+
+		// Показываем исходные xlsx и обработанные json
+		if ext := strings.ToLower(path.Ext(object.Key)); ext != ".xlsx" && ext != ".json" {
 			continue
 		}
 
@@ -106,66 +171,241 @@ func (s *MinIOService) ListFiles(ctx context.Context, prefix string) ([]models.S
 	return files, nil
 }
 
+// ListFilesPage возвращает до limit текущих файлов с именем после after и признак, что есть ещё.
+// minio-go не передаёт StartAfter в листинг версий, поэтому ключи до after пропускаются здесь,
+// а листинг прекращается, как только страница собрана
+func (s *MinIOService) ListFilesPage(ctx context.Context, prefix, after string, limit int) (files []models.ScheduleFile, more bool, err error) {
+	ctx, end := startStorageOperation(ctx, "ListFiles", s.bucket, prefix)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("listing files page", "bucket", s.bucket, "prefix", prefix, "after", after, "limit", limit)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    false,
+		WithVersions: true,
+	}
+	startAfter := ""
+	if after != "" {
+		startAfter = prefix + after
+	}
+
+	for object := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if object.Err != nil {
+			return nil, false, object.Err
+		}
+		if object.Key <= startAfter || strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		if !object.IsLatest || object.IsDeleteMarker {
+			continue
+		}
+		if ext := strings.ToLower(path.Ext(object.Key)); ext != ".xlsx" && ext != ".json" {
+			continue
+		}
+		if len(files) == limit {
+			return files, true, nil
+		}
+		files = append(files, models.ScheduleFile{
+			Name:         extractFileName(object.Key),
+			Path:         object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+			ETag:         object.ETag,
+			Version:      object.VersionID,
+		})
+	}
+
+	return files, false, nil
+}
+
 // GetPresignedURL генерирует presigned URL для скачивания
-func (s *MinIOService) GetPresignedURL(ctx context.Context, objectPath string) (*models.PresignedURLResponse, error) {
+func (s *MinIOService) GetPresignedURL(ctx context.Context, objectPath string) (_ *models.PresignedURLResponse, err error) {
+	ctx, end := startStorageOperation(ctx, "PresignedGetObject", s.bucket, objectPath)
+	defer end(&err)
+
 	reqParams := make(url.Values)
 	reqParams.Set("response-content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", extractFileName(objectPath)))
 
-	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucket, objectPath, s.urlTTL, reqParams)
+	urlTTL := time.Duration(s.urlTTL.Load())
+	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucket, objectPath, urlTTL, reqParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate presigned url: %w", err)
 	}
 
 	return &models.PresignedURLResponse{
 		URL:       presignedURL.String(),
-		ExpiresAt: time.Now().Add(s.urlTTL),
+		ExpiresAt: time.Now().Add(urlTTL),
 		FileName:  extractFileName(objectPath),
 	}, nil
 }
 
 // GetPresignedUploadURL генерирует presigned URL для загрузки (для будущего админа)
-func (s *MinIOService) GetPresignedUploadURL(ctx context.Context, objectPath string) (*models.PresignedURLResponse, error) {
-	presignedURL, err := s.client.PresignedPutObject(ctx, s.bucket, objectPath, s.urlTTL)
+func (s *MinIOService) GetPresignedUploadURL(ctx context.Context, objectPath string) (_ *models.PresignedURLResponse, err error) {
+	ctx, end := startStorageOperation(ctx, "PresignedPutObject", s.bucket, objectPath)
+	defer end(&err)
+
+	urlTTL := time.Duration(s.urlTTL.Load())
+	presignedURL, err := s.client.PresignedPutObject(ctx, s.bucket, objectPath, urlTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate upload url: %w", err)
 	}
 
 	return &models.PresignedURLResponse{
 		URL:       presignedURL.String(),
-		ExpiresAt: time.Now().Add(s.urlTTL),
+		ExpiresAt: time.Now().Add(urlTTL),
 		FileName:  extractFileName(objectPath),
 	}, nil
 }
 
 // ObjectExists проверяет существование объекта
 func (s *MinIOService) ObjectExists(ctx context.Context, objectPath string) (bool, error) {
-	_, err := s.client.StatObject(ctx, s.bucket, objectPath, minio.StatObjectOptions{})
+	return s.ObjectExistsInBucket(ctx, s.bucket, objectPath)
+}
+
+// ObjectExistsInBucket проверяет существование объекта в указанном бакете
+func (s *MinIOService) ObjectExistsInBucket(ctx context.Context, bucket, objectPath string) (bool, error) {
+	info, err := s.StatObjectInBucket(ctx, bucket, objectPath)
 	if err != nil {
-		errResponse := minio.ToErrorResponse(err)
-		if errResponse.Code == "NoSuchKey" {
-			return false, nil
-		}
 		return false, err
 	}
-	return true, nil
+	return info != nil, nil
 }
 
-// ObjectExistsInBucket проверяет существование объекта в указанном бакете
-func (s *MinIOService) ObjectExistsInBucket(ctx context.Context, bucket, objectPath string) (bool, error) {
-	_, err := s.client.StatObject(ctx, bucket, objectPath, minio.StatObjectOptions{})
+// StatObjectInBucket возвращает метаданные объекта или nil, если объекта нет
+func (s *MinIOService) StatObjectInBucket(ctx context.Context, bucket, objectPath string) (_ *models.ScheduleFile, err error) {
+	ctx, end := startStorageOperation(ctx, "StatObject", bucket, objectPath)
+	defer end(&err)
+
+	object, err := s.client.StatObject(ctx, bucket, objectPath, minio.StatObjectOptions{})
 	if err != nil {
 		errResponse := minio.ToErrorResponse(err)
 		if errResponse.Code == "NoSuchKey" {
-			return false, nil
+			return nil, nil
 		}
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	return &models.ScheduleFile{
+		Name:         extractFileName(object.Key),
+		Path:         object.Key,
+		Size:         object.Size,
+		LastModified: object.LastModified,
+		ETag:         object.ETag,
+		Version:      object.VersionID,
+	}, nil
+}
+
+// ListObjectVersions возвращает все версии объекта от новых к старым
+func (s *MinIOService) ListObjectVersions(ctx context.Context, objectPath string) (versions []models.FileVersion, err error) {
+	ctx, end := startStorageOperation(ctx, "ListObjectVersions", s.bucket, objectPath)
+	defer end(&err)
+
+	opts := minio.ListObjectsOptions{
+		Prefix:       objectPath,
+		Recursive:    true,
+		WithVersions: true,
+	}
+
+	for object := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		// Префикс совпадает и с другими файлами, начинающимися с того же имени
+		if object.Key != objectPath {
+			continue
+		}
+		versions = append(versions, models.FileVersion{
+			Version:        object.VersionID,
+			Size:           object.Size,
+			LastModified:   object.LastModified,
+			ETag:           object.ETag,
+			IsLatest:       object.IsLatest,
+			IsDeleteMarker: object.IsDeleteMarker,
+		})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+	return versions, nil
+}
+
+// DownloadVersion скачивает указанную версию объекта. Возвращает nil, если версии нет
+func (s *MinIOService) DownloadVersion(ctx context.Context, objectPath, versionID string) (data []byte, contentType string, err error) {
+	ctx, end := startStorageOperation(ctx, "GetObject", s.bucket, objectPath)
+	defer end(&err)
+
+	object, err := s.client.GetObject(ctx, s.bucket, objectPath, minio.GetObjectOptions{VersionID: versionID})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get object version: %w", err)
+	}
+	defer object.Close()
+
+	info, err := object.Stat()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to get object version: %w", err)
+	}
+
+	data, err = io.ReadAll(object)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object version: %w", err)
+	}
+	return data, info.ContentType, nil
+}
+
+// RestoreVersion делает копию старой версии текущей версией объекта (copy-in-place).
+// История при этом не теряется: восстановленная копия становится новой версией
+func (s *MinIOService) RestoreVersion(ctx context.Context, objectPath, versionID string) (restored *models.FileVersion, err error) {
+	ctx, end := startStorageOperation(ctx, "CopyObject", s.bucket, objectPath)
+	defer end(&err)
+
+	info, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: objectPath},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: objectPath, VersionID: versionID},
+	)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to restore version: %w", err)
+	}
+
+	return &models.FileVersion{
+		Version:      info.VersionID,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+		ETag:         info.ETag,
+		IsLatest:     true,
+	}, nil
+}
+
+// BucketExists проверяет существование бакета; ошибка означает недоступность хранилища
+func (s *MinIOService) BucketExists(ctx context.Context, bucket string) (exists bool, err error) {
+	ctx, end := startStorageOperation(ctx, "BucketExists", bucket, "")
+	defer end(&err)
+
+	return s.client.BucketExists(ctx, bucket)
+}
+
+// Bucket возвращает бакет обработанных расписаний (TARGET_BUCKET), из которого они отдаются
+func (s *MinIOService) Bucket() string {
+	return s.bucket
+}
+
+// Endpoint возвращает адрес MinIO
+func (s *MinIOService) Endpoint() string {
+	return s.client.EndpointURL().Host
 }
 
 // ListAllObjectsInBucket возвращает список всех объектов в указанном бакете с префиксом
-func (s *MinIOService) ListAllObjectsInBucket(ctx context.Context, bucket, prefix string) ([]string, error) {
-	var objects []string
+func (s *MinIOService) ListAllObjectsInBucket(ctx context.Context, bucket, prefix string) (objects []string, err error) {
+	ctx, end := startStorageOperation(ctx, "ListObjects", bucket, prefix)
+	defer end(&err)
 
 	opts := minio.ListObjectsOptions{
 		Prefix:    prefix,
@@ -183,7 +423,11 @@ func (s *MinIOService) ListAllObjectsInBucket(ctx context.Context, bucket, prefi
 }
 
 // DownloadFile скачивает файл из указанного бакета
-func (s *MinIOService) DownloadFile(ctx context.Context, bucket, objectPath string) ([]byte, error) {
+func (s *MinIOService) DownloadFile(ctx context.Context, bucket, objectPath string) (_ []byte, err error) {
+	ctx, end := startStorageOperation(ctx, "GetObject", bucket, objectPath)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("downloading object", "bucket", bucket, "path", objectPath)
+
 	object, err := s.client.GetObject(ctx, bucket, objectPath, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object: %w", err)
@@ -199,18 +443,89 @@ func (s *MinIOService) DownloadFile(ctx context.Context, bucket, objectPath stri
 	return data, nil
 }
 
-// UploadFile загружает файл в указанный бакет
-func (s *MinIOService) UploadFile(ctx context.Context, bucket, objectPath string, reader io.Reader, size int64, contentType string) error {
-	_, err := s.client.PutObject(ctx, bucket, objectPath, reader, size, minio.PutObjectOptions{
+// UploadFile загружает файл в указанный бакет и возвращает идентификатор созданной версии
+// (пустой, если версионирование бакета выключено)
+func (s *MinIOService) UploadFile(ctx context.Context, bucket, objectPath string, reader io.Reader, size int64, contentType string) (versionID string, err error) {
+	ctx, end := startStorageOperation(ctx, "PutObject", bucket, objectPath)
+	defer end(&err)
+	logging.FromContext(ctx).Debug("uploading object", "bucket", bucket, "path", objectPath, "size", size)
+
+	info, err := s.client.PutObject(ctx, bucket, objectPath, reader, size, minio.PutObjectOptions{
 		ContentType: contentType,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upload object: %w", err)
+		return "", fmt.Errorf("failed to upload object: %w", err)
 	}
-	return nil
+	return info.VersionID, nil
 }
 
 // Вспомогательные функции
+
+// startStorageOperation открывает спан вызова MinIO; возвращённая функция завершает спан
+// и учитывает вызов в метриках. err передаётся по указателю, чтобы в defer было видно итоговое значение
+func startStorageOperation(ctx context.Context, operation, bucket, key string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "minio."+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("storage.bucket", bucket),
+			attribute.String("storage.key", key),
+		))
+
+	return ctx, func(err *error) {
+		metrics.ObserveStorage(operation, start, *err)
+		tracing.End(span, *err)
+	}
+}
+
+// Классы ошибок хранилища; исходная ошибка MinIO остаётся в логах, клиенту отдаётся только класс
+var (
+	ErrStorageNotFound     = errors.New("object not found")
+	ErrStorageTimeout      = errors.New("storage timeout")
+	ErrStorageUnavailable  = errors.New("storage unavailable")
+	ErrStorageAccessDenied = errors.New("storage access denied")
+)
+
+// ClassifyStorageError относит ошибку MinIO к одному из классов ErrStorage*; nil — класс не определён
+func ClassifyStorageError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrStorageTimeout
+	}
+
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) {
+		switch resp.Code {
+		case "NoSuchKey", "NoSuchVersion":
+			return ErrStorageNotFound
+		case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch":
+			return ErrStorageAccessDenied
+		case "NoSuchBucket", "SlowDown", "ServiceUnavailable", "XMinioServerNotInitialized":
+			return ErrStorageUnavailable
+		case "RequestTimeout":
+			return ErrStorageTimeout
+		}
+		return nil
+	}
+
+	// Ошибки соединения: MinIO не запущен, DNS, обрыв
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrStorageTimeout
+		}
+		return ErrStorageUnavailable
+	}
+	return nil
+}
+
+// isNotFound сообщает, что объекта или его версии не существует
+func isNotFound(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchVersion", "InvalidArgument":
+		return true
+	}
+	return false
+}
+
 func extractFileName(path string) string {
 	parts := strings.Split(path, "/")
 	return parts[len(parts)-1]
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"schedule-api/config"
+	"schedule-api/internal/s3test"
+)
+
+// newTestMinIO подключает MinIOService к хранилищу в памяти с бакетами schedule-api и schedules (TARGET_BUCKET)
+func newTestMinIO(t *testing.T) (*MinIOService, *s3test.Server) {
+	t.Helper()
+	server := s3test.NewServer(t, "schedule-api", "schedules")
+	minio, err := NewMinIOService(&config.Config{
+		MinIOEndpoint: server.Endpoint(),
+		MinIOBucket:   "schedule-api",
+		TargetBucket:  "schedules",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return minio, server
+}
+
+func TestMinIOVersions(t *testing.T) {
+	minio, server := newTestMinIO(t)
+	const path = "kgu/1/regular/a.json"
+	v1 := server.Put("schedules", path, []byte(`{"v":1}`))
+	v2 := server.Put("schedules", path, []byte(`{"v":2}`))
+	server.Put("schedule-api", path, []byte(`{"other":"bucket"}`))
+
+	versions, err := minio.ListObjectVersions(t.Context(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(versions))
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if !slices.Equal(got, []string{v2, v1}) || !versions[0].IsLatest || versions[1].IsLatest {
+		t.Fatalf("versions = %+v, want %s (latest), %s", versions, v2, v1)
+	}
+
+	data, _, err := minio.DownloadVersion(t.Context(), path, v1)
+	if err != nil || string(data) != `{"v":1}` {
+		t.Errorf("DownloadVersion(%s) = %s, %v", v1, data, err)
+	}
+	if data, _, err := minio.DownloadVersion(t.Context(), path, "missing"); data != nil || err != nil {
+		t.Errorf("missing version: %s, %v; want nil, nil", data, err)
+	}
+}
+
+func TestMinIORestoreVersion(t *testing.T) {
+	minio, server := newTestMinIO(t)
+	const path = "kgu/1/regular/a.json"
+	v1 := server.Put("schedules", path, []byte(`{"v":1}`))
+	server.Put("schedules", path, []byte(`{"v":2}`))
+
+	restored, err := minio.RestoreVersion(t.Context(), path, v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored == nil || restored.Version == v1 || !restored.IsLatest {
+		t.Fatalf("restored = %+v, want a new latest version", restored)
+	}
+	// Копия становится текущей версией, история сохраняется
+	if data, _ := server.Get("schedules", path); string(data) != `{"v":1}` {
+		t.Errorf("current content = %s, want version %s", data, v1)
+	}
+	if versions, _ := minio.ListObjectVersions(t.Context(), path); len(versions) != 3 {
+		t.Errorf("versions after restore = %d, want 3", len(versions))
+	}
+
+	if restored, err := minio.RestoreVersion(t.Context(), path, "missing"); restored != nil || err != nil {
+		t.Errorf("missing version: %+v, %v; want nil, nil", restored, err)
+	}
+
+	// Версии читаются и восстанавливаются только в TARGET_BUCKET
+	for _, request := range server.Requests() {
+		if strings.Contains(request, "schedule-api") {
+			t.Errorf("request to MINIO_BUCKET: %s", request)
+		}
+	}
+}
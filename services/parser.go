@@ -1,16 +1,35 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"regexp"
+	"schedule-api/logging"
+	"schedule-api/metrics"
 	"schedule-api/models"
+	"schedule-api/tracing"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ошибки разбора XLSX; по ним обработчик выбирает код ошибки для клиента
+var (
+	ErrInvalidXLSX         = errors.New("invalid xlsx file")
+	ErrNoSheets            = errors.New("no sheets found")
+	ErrTooFewRows          = errors.New("too few rows")
+	ErrNoGroups            = errors.New("no groups found")
+	ErrUnknownScheduleType = errors.New("unknown schedule type")
 )
 
 type ParserService struct{}
@@ -20,27 +39,48 @@ func NewParserService() *ParserService {
 }
 
 // ParseXLSXToJSON парсит XLSX в JSON
-func (s *ParserService) ParseXLSXToJSON(file io.Reader, scheduleType string) ([]byte, error) {
+func (s *ParserService) ParseXLSXToJSON(ctx context.Context, file io.Reader, scheduleType string) (_ []byte, err error) {
+	ctx, span := tracing.Start(ctx, "parser.ParseXLSXToJSON", trace.WithAttributes(attribute.String("schedule.type", scheduleType)))
+	logger := logging.FromContext(ctx).With("schedule_type", scheduleType)
+	defer func(start time.Time) {
+		metrics.ObserveParse(scheduleType, start, err)
+		logger.Debug("schedule parsed", "duration", time.Since(start), "error", err)
+		tracing.End(span, err)
+	}(time.Now())
+
+	_, openSpan := tracing.Start(ctx, "parser.open_workbook")
 	f, err := excelize.OpenReader(file)
+	tracing.End(openSpan, err)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open xlsx: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidXLSX, err)
 	}
 	defer f.Close()
 
+	_, extractSpan := tracing.Start(ctx, "parser.extract")
+	var schedule interface{}
 	switch scheduleType {
 	case "основное", "main":
-		return s.parseRegularSchedule(f)
+		schedule, err = s.parseRegularSchedule(f, logger)
 	case "замены", "replacements":
-		return s.parseReplacementSchedule(f)
+		schedule, err = s.parseReplacementSchedule(f)
 	case "экзамены", "exams":
-		return s.parseExamSchedule(f)
+		schedule, err = s.parseExamSchedule(f)
 	default:
-		return nil, fmt.Errorf("unknown schedule type: %s", scheduleType)
+		err = fmt.Errorf("%w: %s", ErrUnknownScheduleType, scheduleType)
 	}
+	tracing.End(extractSpan, err)
+	if err != nil {
+		return nil, err
+	}
+
+	_, marshalSpan := tracing.Start(ctx, "parser.marshal")
+	data, err := json.MarshalIndent(schedule, "", "  ")
+	tracing.End(marshalSpan, err)
+	return data, err
 }
 
 // parseRegularSchedule парсит основное расписание
-func (s *ParserService) parseRegularSchedule(f *excelize.File) ([]byte, error) {
+func (s *ParserService) parseRegularSchedule(f *excelize.File, logger *slog.Logger) (*models.RegularSchedule, error) {
 	sheet := f.GetSheetList()[0]
 	rows, err := f.GetRows(sheet)
 	if err != nil {
@@ -48,7 +88,7 @@ func (s *ParserService) parseRegularSchedule(f *excelize.File) ([]byte, error) {
 	}
 
 	if len(rows) < 10 {
-		return nil, fmt.Errorf("insufficient rows in schedule file")
+		return nil, fmt.Errorf("%w: need at least 10 rows, got %d", ErrTooFewRows, len(rows))
 	}
 
 	schedule := models.RegularSchedule{
@@ -63,17 +103,17 @@ func (s *ParserService) parseRegularSchedule(f *excelize.File) ([]byte, error) {
 
 	// Находим строку с номерами групп (строка 9, индекс 8)
 	if len(rows) < 9 {
-		return nil, fmt.Errorf("insufficient rows: need at least 9 rows, got %d", len(rows))
+		return nil, fmt.Errorf("%w: need at least 9 rows, got %d", ErrTooFewRows, len(rows))
 	}
 
 	groupRow := rows[8]
 	groupPositions := s.findGroupPositions(groupRow)
 
 	if len(groupPositions) == 0 {
-		return nil, fmt.Errorf("no groups found in schedule (row 9). Row content: %v", groupRow)
+		return nil, fmt.Errorf("%w in row 9: %v", ErrNoGroups, groupRow)
 	}
 
-	log.Printf("Найдено групп: %d", len(groupPositions))
+	logger.Debug("groups found", "count", len(groupPositions))
 
 	// Извлекаем направления (строка 8, индекс 7)
 	directionRow := rows[7]
@@ -113,7 +153,7 @@ func (s *ParserService) parseRegularSchedule(f *excelize.File) ([]byte, error) {
 
 			// Парсим новый день
 			currentDay, currentDate = s.parseDayCell(dayCell)
-			log.Printf("Обработка нового дня: %s (%s)", currentDay, currentDate)
+			logger.Debug("parsing day", "day", currentDay, "date", currentDate)
 
 			// Инициализируем DaySchedule для каждой группы
 			currentDaySchedules = make([]*models.DaySchedule, len(groupPositions))
@@ -155,7 +195,7 @@ func (s *ParserService) parseRegularSchedule(f *excelize.File) ([]byte, error) {
 		}
 	}
 
-	return json.MarshalIndent(schedule, "", "  ")
+	return &schedule, nil
 }
 
 // Вспомогательные функции
@@ -246,22 +286,20 @@ func (s *ParserService) parseLessons(row []string, startCol int, endCol int, tim
 
 		// Проверяем, это дисциплина (содержит скобки с типом занятия или длинный текст)
 		if strings.Contains(cell, "(") || len(cell) > 10 {
-			lesson := models.Lesson{
-				Time: time,
-			}
+			// Ячейка с несколькими подгруппами даёт по занятию на подгруппу
+			cellLessons := s.parseDiscipline(cell)
 
-			// Парсим дисциплину
-			lesson.Subject, lesson.Teacher, lesson.Type, lesson.SubGroup = s.parseDiscipline(cell)
-
-			// Ищем аудиторию в следующих 1-3 колонках
+			// Ищем аудитории в следующих 1-3 колонках: по одной на всю ячейку или на каждую подгруппу
+			classrooms := make([]string, 0)
 			for offset := 1; offset <= 3 && i+offset < endCol && i+offset < len(row); offset++ {
 				audCell := s.cleanValue(row[i+offset])
 				if audCell != "" {
 					// Аудитория обычно короткая и не содержит скобок
-					if len(audCell) <= 5 || audCell == "с/з" {
-						lesson.Classroom = audCell
+					if len(audCell) <= 5 || audCell == "с/з" || isClassroomList(audCell) {
+						classrooms = append(classrooms, splitClassrooms(audCell)...)
 						i += offset // Пропускаем обработанные ячейки
-						break
+						offset = 0
+						continue
 					} else if strings.Contains(audCell, "(") {
 						// Это следующая дисциплина, не аудитория
 						break
@@ -269,9 +307,21 @@ func (s *ParserService) parseLessons(row []string, startCol int, endCol int, tim
 				}
 			}
 
-			// Добавляем урок только если есть предмет
-			if lesson.Subject != "" {
-				lessons = append(lessons, lesson)
+			for idx := range cellLessons {
+				lesson := &cellLessons[idx]
+				lesson.Time = time
+				switch {
+				case lesson.Classroom != "":
+				case len(classrooms) == len(cellLessons):
+					lesson.Classroom = classrooms[idx]
+				case len(classrooms) > 0:
+					lesson.Classroom = strings.Join(classrooms, ", ")
+				}
+
+				// Добавляем урок только если есть предмет
+				if lesson.Subject != "" {
+					lessons = append(lessons, *lesson)
+				}
 			}
 		}
 
@@ -281,64 +331,115 @@ func (s *ParserService) parseLessons(row []string, startCol int, endCol int, tim
 	return lessons
 }
 
-// parseDiscipline разбирает строку дисциплины
-func (s *ParserService) parseDiscipline(text string) (subject, teacher, lessonType, subGroup string) {
+var (
+	subGroupPattern      = regexp.MustCompile(`\d+\s*п/г\.?`)
+	lessonTypePattern    = regexp.MustCompile(`\((лек\.|пр\.|лаб\.)\)`)
+	lessonTeacherPattern = regexp.MustCompile(`[А-ЯЁ][а-яё]+(?:-[А-ЯЁ][а-яё]+)?\s+[А-ЯЁ]\.\s*[А-ЯЁ]\.?`)
+	lessonRoomPattern    = regexp.MustCompile(`(?i)ауд\.?\s*(\S+)`)
+	classroomListPattern = regexp.MustCompile(`^\d+[а-я]?(?:\s*[,;/]\s*\d+[а-я]?)+$`)
+)
+
+// parseDiscipline разбирает строку дисциплины в занятия — по одному на подгруппу.
+// У каждой подгруппы свои преподаватели и, если указана, аудитория; несколько
+// преподавателей без подгрупп означают совместное занятие
+func (s *ParserService) parseDiscipline(text string) []models.Lesson {
 	// Формат: "Математика (пр.) Гареева Г.А."
-	// Или: "Иностранный язык (пр.) 1п/г Буланова Л.Н."
+	// Или: "Иностранный язык (пр.) 1п/г Буланова Л.Н. 2п/г Петров И.И."
+	// Или: "Физика (лаб.) Иванов И.И., Петров П.П."
 	// Или: "Физическая культура и спорт (элективная дисциплина) Телешев С.А."
 
 	text = strings.TrimSpace(text)
 	if text == "" {
-		return
+		return nil
 	}
 
 	original := text
 
-	// Извлекаем подгруппу
-	if strings.Contains(text, "п/г") {
-		re := regexp.MustCompile(`(\d+\s*п/г)`)
-		matches := re.FindStringSubmatch(text)
-		if len(matches) > 1 {
-			subGroup = matches[1]
-			text = strings.Replace(text, subGroup, "", 1)
-		}
-	}
-
 	// Извлекаем тип занятия (лек., пр., лаб.)
-	typePattern := regexp.MustCompile(`\((лек\.|пр\.|лаб\.)\)`)
-	matches := typePattern.FindStringSubmatch(text)
-	if len(matches) > 1 {
+	lessonType := ""
+	if matches := lessonTypePattern.FindStringSubmatch(text); len(matches) > 1 {
 		lessonType = matches[1]
-		text = typePattern.ReplaceAllString(text, "")
+		text = lessonTypePattern.ReplaceAllString(text, "")
 	}
 
 	// Удаляем дополнительные описания в скобках (например, "элективная дисциплина")
 	text = regexp.MustCompile(`\([^)]+\)`).ReplaceAllString(text, "")
 
-	// Оставшееся: "Математика Гареева Г.А."
-	text = strings.TrimSpace(text)
-	parts := strings.Fields(text)
-	if len(parts) == 0 {
-		subject = original
-		return
+	if strings.TrimSpace(text) == "" {
+		return []models.Lesson{{Subject: original, Type: lessonType}}
 	}
 
-	// ФИО обычно последние 2-3 слова с инициалами
-	fioPattern := regexp.MustCompile(`[А-ЯЁ][а-яё]+\s+[А-ЯЁ]\.[А-ЯЁ]\.?`)
-	fioMatch := fioPattern.FindString(text)
+	// Делим на общую часть и части подгрупп: "<общая> 1п/г <часть> 2п/г <часть>"
+	markers := subGroupPattern.FindAllStringIndex(text, -1)
+	if len(markers) == 0 {
+		lesson := s.parseLessonPart(text)
+		lesson.Type = lessonType
+		return []models.Lesson{lesson}
+	}
 
-	if fioMatch != "" {
-		teacher = strings.TrimSpace(fioMatch)
-		subject = strings.TrimSpace(strings.Replace(text, fioMatch, "", 1))
-	} else {
-		// Если не нашли ФИО, всё считаем предметом
-		subject = text
+	common := s.parseLessonPart(text[:markers[0][0]])
+	lessons := make([]models.Lesson, 0, len(markers))
+	for idx, marker := range markers {
+		partEnd := len(text)
+		if idx+1 < len(markers) {
+			partEnd = markers[idx+1][0]
+		}
+
+		lesson := s.parseLessonPart(text[marker[1]:partEnd])
+		lesson.SubGroup = strings.TrimSuffix(strings.TrimSpace(text[marker[0]:marker[1]]), ".")
+		lesson.Type = lessonType
+		if lesson.Subject == "" {
+			lesson.Subject = common.Subject
+		}
+		// "Иностранный язык Буланова Л.Н. 1п/г": преподаватель стоит до отметки подгруппы
+		if len(lesson.Teachers) == 0 && len(markers) == 1 {
+			lesson.Teacher, lesson.Teachers, lesson.TeacherID, lesson.TeacherIDs = common.Teacher, common.Teachers, common.TeacherID, common.TeacherIDs
+		}
+		if lesson.Classroom == "" {
+			lesson.Classroom = common.Classroom
+		}
+		lessons = append(lessons, lesson)
 	}
+	return lessons
+}
 
-	subject = strings.TrimSpace(subject)
-	teacher = strings.TrimSpace(teacher)
+// parseLessonPart извлекает из части ячейки преподавателей и аудиторию; остаток — дисциплина
+func (s *ParserService) parseLessonPart(text string) models.Lesson {
+	var lesson models.Lesson
 
-	return
+	if matches := lessonRoomPattern.FindStringSubmatch(text); len(matches) > 1 {
+		lesson.Classroom = strings.TrimRight(matches[1], ".,;")
+		text = strings.Replace(text, matches[0], " ", 1)
+	}
+
+	// ФИО обычно последние 2-3 слова с инициалами
+	for _, fio := range lessonTeacherPattern.FindAllString(text, -1) {
+		teacher := models.NormalizeTeacherName(fio)
+		if !slices.Contains(lesson.Teachers, teacher) {
+			lesson.Teachers = append(lesson.Teachers, teacher)
+			lesson.TeacherIDs = append(lesson.TeacherIDs, models.TeacherID(teacher))
+		}
+		text = strings.Replace(text, fio, " ", 1)
+	}
+	if len(lesson.Teachers) > 0 {
+		lesson.Teacher, lesson.TeacherID = lesson.Teachers[0], lesson.TeacherIDs[0]
+	}
+
+	lesson.Subject = strings.Trim(strings.Join(strings.Fields(text), " "), " ,;")
+	return lesson
+}
+
+// isClassroomList проверяет, что ячейка перечисляет аудитории подгрупп: "305/307", "305, 307"
+func isClassroomList(value string) bool {
+	return classroomListPattern.MatchString(value)
+}
+
+// splitClassrooms делит список аудиторий подгрупп; "с/з" и одиночная аудитория не делятся
+func splitClassrooms(value string) []string {
+	if !isClassroomList(value) {
+		return []string{value}
+	}
+	return regexp.MustCompile(`\s*[,;/]\s*`).Split(value, -1)
 }
 
 // extractWeekType извлекает тип недели
@@ -422,48 +523,215 @@ func (s *ParserService) cleanValue(value string) string {
 	return value
 }
 
-// parseReplacementSchedule парсит расписание замен
-func (s *ParserService) parseReplacementSchedule(f *excelize.File) ([]byte, error) {
+// replacementColumns — номера колонок таблицы замен; -1 — колонки нет
+type replacementColumns struct {
+	date, group, time           int
+	originalSubject, newSubject int
+	originalTeacher, newTeacher int
+	classroom, reason           int
+}
+
+// legacyReplacementColumns — формат без строки заголовков:
+// время, дисциплина по расписанию, замена, преподаватель по расписанию, новый преподаватель, аудитория
+var legacyReplacementColumns = replacementColumns{
+	date: -1, group: -1, time: 0,
+	originalSubject: 1, newSubject: 2,
+	originalTeacher: 3, newTeacher: 4,
+	classroom: 5, reason: -1,
+}
+
+var (
+	groupCellPattern   = regexp.MustCompile(`(?i)^(?:гр(?:уппа|\.)?\s*)?(\d{5})$`)
+	cancelledPattern   = regexp.MustCompile(`(?i)отмен|снят|нет\s+(?:пары|занятия)`)
+	noReplacementCells = map[string]bool{"-": true, "–": true, "—": true}
+)
+
+// parseReplacementSchedule парсит расписание замен.
+// Дата берётся из заголовка ("Замены на 12.01.2026"), из строк-разделителей между днями
+// или из колонки даты; группа — из колонки группы или строк-разделителей вида "Группа 23101"
+func (s *ParserService) parseReplacementSchedule(f *excelize.File) (*models.ReplacementSchedule, error) {
 	sheet := f.GetSheetList()[0]
 	rows, err := f.GetRows(sheet)
 	if err != nil {
 		return nil, err
 	}
 
+	// Группа часто объединена на несколько строк, дата дня — на всю ширину таблицы
+	rows, err = s.fillMergedCells(f, sheet, rows)
+	if err != nil {
+		return nil, err
+	}
+
 	schedule := models.ReplacementSchedule{
 		Type:         "replacements",
 		UpdatedAt:    time.Now(),
-		Date:         time.Now().Format("2006-01-02"),
 		Replacements: make([]models.Replacement, 0),
 	}
 
-	// Пропускаем заголовок и парсим данные
-	for i := 2; i < len(rows); i++ {
-		row := rows[i]
-		if len(row) < 6 {
+	columns, headerRow := legacyReplacementColumns, -1
+	for i := 0; i < len(rows) && i < 15; i++ {
+		if found, ok := s.findReplacementColumns(rows[i]); ok {
+			columns, headerRow = found, i
+			break
+		}
+	}
+
+	currentDate, currentGroup := "", ""
+	for i, row := range rows {
+		if i == headerRow {
 			continue
 		}
 
-		timeSlot := s.cleanValue(row[0])
-		if timeSlot == "" {
+		// Строка-разделитель: одно значение на всю строку — дата, группа или заголовок документа
+		if values := s.distinctValues(row); len(values) == 1 || (headerRow < 0 && i < 2) || i < headerRow {
+			for _, value := range values {
+				if date, _ := parseDateCell(value); date != "" {
+					currentDate = date
+				}
+				if m := groupCellPattern.FindStringSubmatch(value); m != nil {
+					currentGroup = m[1]
+				}
+			}
 			continue
 		}
 
-		schedule.Replacements = append(schedule.Replacements, models.Replacement{
-			Time:            timeSlot,
-			OriginalSubject: s.cleanValue(row[1]),
-			NewSubject:      s.cleanValue(row[2]),
-			OriginalTeacher: s.cleanValue(row[3]),
-			NewTeacher:      s.cleanValue(row[4]),
-			Classroom:       s.cleanValue(row[5]),
-		})
+		cell := func(col int) string {
+			if col < 0 || col >= len(row) {
+				return ""
+			}
+			return s.cleanValue(row[col])
+		}
+
+		replacement := models.Replacement{
+			Date:            currentDate,
+			Group:           currentGroup,
+			Time:            cell(columns.time),
+			OriginalSubject: cell(columns.originalSubject),
+			NewSubject:      cell(columns.newSubject),
+			OriginalTeacher: cell(columns.originalTeacher),
+			NewTeacher:      cell(columns.newTeacher),
+			Classroom:       cell(columns.classroom),
+			Reason:          cell(columns.reason),
+			Status:          models.ReplacementStatusReplaced,
+		}
+		if replacement.Time == "" || (replacement.OriginalSubject == "" && replacement.NewSubject == "" && replacement.NewTeacher == "") {
+			continue
+		}
+		if date, _ := parseDateCell(cell(columns.date)); date != "" {
+			replacement.Date = date
+		}
+		if group := cell(columns.group); group != "" {
+			if m := groupCellPattern.FindStringSubmatch(group); m != nil {
+				group = m[1]
+			}
+			replacement.Group = group
+		}
+		s.detectCancellation(&replacement)
+		replacement.OriginalTeacher, replacement.OriginalTeacherID = normalizeTeacher(replacement.OriginalTeacher)
+		replacement.NewTeacher, replacement.NewTeacherID = normalizeTeacher(replacement.NewTeacher)
+
+		schedule.Replacements = append(schedule.Replacements, replacement)
+	}
+
+	for _, replacement := range schedule.Replacements {
+		if replacement.Date != "" && !slices.Contains(schedule.Dates, replacement.Date) {
+			schedule.Dates = append(schedule.Dates, replacement.Date)
+		}
+		if replacement.Group != "" && !slices.Contains(schedule.Groups, replacement.Group) {
+			schedule.Groups = append(schedule.Groups, replacement.Group)
+		}
+	}
+	slices.Sort(schedule.Dates)
+	if len(schedule.Dates) > 0 {
+		schedule.Date = schedule.Dates[0]
+	}
+
+	return &schedule, nil
+}
+
+// findReplacementColumns распознаёт строку заголовков таблицы замен по названиям колонок.
+// Колонки дисциплины и преподавателя идут парами: первая — по расписанию, вторая — замена
+func (s *ParserService) findReplacementColumns(row []string) (replacementColumns, bool) {
+	columns := replacementColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1}
+	assignPair := func(original, replacement *int, col int, header string) {
+		switch {
+		case strings.Contains(header, "по расписанию") || strings.Contains(header, "было") || strings.Contains(header, "отсутств"):
+			*original = col
+		case strings.Contains(header, "замен") || strings.Contains(header, "стало") || strings.Contains(header, "нов"):
+			*replacement = col
+		case *original < 0:
+			*original = col
+		default:
+			*replacement = col
+		}
+	}
+
+	matched := 0
+	for col, value := range row {
+		header := strings.ToLower(s.cleanValue(value))
+		if header == "" {
+			continue
+		}
+		matched++
+		switch {
+		case strings.Contains(header, "дата"):
+			columns.date = col
+		case strings.Contains(header, "групп"):
+			columns.group = col
+		case strings.Contains(header, "причин") || strings.Contains(header, "примечан"):
+			columns.reason = col
+		case strings.Contains(header, "ауд"):
+			columns.classroom = col
+		case strings.Contains(header, "преподават"):
+			assignPair(&columns.originalTeacher, &columns.newTeacher, col, header)
+		case strings.Contains(header, "дисциплин") || strings.Contains(header, "предмет") || strings.Contains(header, "замен"):
+			assignPair(&columns.originalSubject, &columns.newSubject, col, header)
+		case strings.Contains(header, "пара") || strings.Contains(header, "время"):
+			columns.time = col
+		default:
+			matched--
+		}
+	}
+
+	ok := matched >= 3 && columns.time >= 0 && (columns.originalSubject >= 0 || columns.newSubject >= 0)
+	return columns, ok
+}
+
+// detectCancellation отмечает отменённое занятие: "отмена", "снято", "нет пары" или прочерк вместо замены.
+// Пояснение в скобках ("Отмена (болезнь преподавателя)") становится причиной, если колонки причины нет
+func (s *ParserService) detectCancellation(replacement *models.Replacement) {
+	text := replacement.NewSubject
+	switch {
+	case noReplacementCells[text]:
+	case cancelledPattern.MatchString(text):
+		if m := regexp.MustCompile(`\(([^)]+)\)`).FindStringSubmatch(text); m != nil && replacement.Reason == "" {
+			replacement.Reason = strings.TrimSpace(m[1])
+		}
+	case text == "" && replacement.NewTeacher == "" && replacement.Classroom == "":
+	case cancelledPattern.MatchString(replacement.Reason) && text == "":
+	default:
+		return
 	}
+	replacement.Status = models.ReplacementStatusCancelled
+	replacement.NewSubject = ""
+}
 
-	return json.MarshalIndent(schedule, "", "  ")
+// distinctValues возвращает непустые значения строки без повторов (повторы — следы объединённых ячеек)
+func (s *ParserService) distinctValues(row []string) []string {
+	values := make([]string, 0)
+	for _, cell := range row {
+		cell = s.cleanValue(cell)
+		if cell != "" && !slices.Contains(values, cell) {
+			values = append(values, cell)
+		}
+	}
+	return values
 }
 
-// parseExamSchedule парсит экзаменационное расписание
-func (s *ParserService) parseExamSchedule(f *excelize.File) ([]byte, error) {
+// parseExamSchedule парсит экзаменационное расписание.
+// Если в заголовке есть строка с номерами групп, мероприятия читаются по колонкам групп,
+// иначе — в старом формате из пяти колонок: дата, время, дисциплина, преподаватель, аудитория
+func (s *ParserService) parseExamSchedule(f *excelize.File) (*models.ExamSchedule, error) {
 	sheet := f.GetSheetList()[0]
 	rows, err := f.GetRows(sheet)
 	if err != nil {
@@ -476,40 +744,340 @@ func (s *ParserService) parseExamSchedule(f *excelize.File) ([]byte, error) {
 		Exams:     make([]models.Exam, 0),
 	}
 
+	// Строку групп ищем до заполнения объединённых ячеек, чтобы номер группы,
+	// растянутый на несколько колонок, не превратился в несколько групп
+	headerRow, groupPositions := -1, []GroupPosition(nil)
+	for i := 0; i < len(rows) && i < 15; i++ {
+		if positions := s.findGroupPositions(rows[i]); len(positions) > 0 {
+			headerRow, groupPositions = i, positions
+			// Строка групп обрывается на номере последней группы, а её колонки идут до конца строки
+			groupPositions[len(groupPositions)-1].EndColumn = math.MaxInt
+			break
+		}
+	}
+
+	// Объединённые ячейки (дата на несколько строк, экзамен на несколько групп)
+	// excelize возвращает только в левой верхней ячейке — распространяем значение на весь диапазон
+	rows, err = s.fillMergedCells(f, sheet, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if headerRow < 0 {
+		s.parseExamRows(rows, &schedule)
+	} else {
+		groups := make([]string, len(groupPositions))
+		for idx, pos := range groupPositions {
+			groups[idx] = s.cleanValue(rows[headerRow][pos.Column])
+		}
+		s.parseGroupExamRows(rows, headerRow, groupPositions, groups, &schedule)
+	}
+	schedule.Groups = ExamGroups(&schedule)
+
+	for _, exam := range schedule.Exams {
+		if !isISODate(exam.Date) {
+			continue
+		}
+		end := exam.Date
+		if exam.EndDate != "" {
+			end = exam.EndDate
+		}
+		if schedule.StartDate == "" || exam.Date < schedule.StartDate {
+			schedule.StartDate = exam.Date
+		}
+		if end > schedule.EndDate {
+			schedule.EndDate = end
+		}
+	}
+
+	return &schedule, nil
+}
+
+// parseExamRows разбирает старый формат без колонок групп
+func (s *ParserService) parseExamRows(rows [][]string, schedule *models.ExamSchedule) {
+	currentDate, currentEndDate := "", ""
 	for i := 2; i < len(rows); i++ {
 		row := rows[i]
 		if len(row) < 5 {
 			continue
 		}
 
-		date := s.cleanValue(row[0])
-		if date == "" {
+		if cell := s.cleanValue(row[0]); cell != "" {
+			currentDate, currentEndDate = parseDateCell(cell)
+			if currentDate == "" {
+				currentDate = cell
+			}
+		}
+		subject := s.cleanValue(row[2])
+		if currentDate == "" || subject == "" {
 			continue
 		}
 
+		kind, subject := extractExamKind(subject)
+		teacher, teacherID := normalizeTeacher(s.cleanValue(row[3]))
 		schedule.Exams = append(schedule.Exams, models.Exam{
-			Date:      date,
+			Date:      currentDate,
+			EndDate:   currentEndDate,
 			Time:      s.cleanValue(row[1]),
-			Subject:   s.cleanValue(row[2]),
-			Teacher:   s.cleanValue(row[3]),
+			Kind:      kind,
+			Subject:   subject,
+			Teacher:   teacher,
+			TeacherID: teacherID,
 			Classroom: s.cleanValue(row[4]),
 		})
 	}
+}
+
+// parseGroupExamRows разбирает формат с колонками групп: слева от первой группы — дата и время,
+// в колонках группы — описание мероприятия и аудитория
+func (s *ParserService) parseGroupExamRows(rows [][]string, headerRow int, groupPositions []GroupPosition, groups []string, schedule *models.ExamSchedule) {
+	schedule.Groups = groups
+
+	firstGroupColumn := groupPositions[0].Column
+	currentDate, currentEndDate := "", ""
+
+	for i := headerRow + 1; i < len(rows); i++ {
+		row := rows[i]
+
+		// Дата и время могут стоять в любой колонке перед группами
+		timeCell := ""
+		for col := 0; col < firstGroupColumn && col < len(row); col++ {
+			cell := s.cleanValue(row[col])
+			if cell == "" {
+				continue
+			}
+			if date, endDate := parseDateCell(cell); date != "" {
+				currentDate, currentEndDate = date, endDate
+			} else if examTimePattern.MatchString(cell) && timeCell == "" {
+				timeCell = cell
+			}
+		}
+		if currentDate == "" {
+			continue
+		}
+
+		for idx, pos := range groupPositions {
+			description, classroom := s.examCellText(row, pos.Column, pos.EndColumn)
+			if description == "" {
+				continue
+			}
+
+			exam := s.parseExamCell(description)
+			exam.Date = currentDate
+			exam.EndDate = currentEndDate
+			exam.Time = timeCell
+			exam.Group = groups[idx]
+			if classroom != "" {
+				exam.Classroom = classroom
+			}
+			if exam.Subject != "" {
+				schedule.Exams = append(schedule.Exams, exam)
+			}
+		}
+	}
+}
+
+// examCellText собирает описание мероприятия и аудиторию из колонок группы.
+// Одинаковые соседние значения (следы объединённой ячейки) учитываются один раз
+func (s *ParserService) examCellText(row []string, startCol, endCol int) (description, classroom string) {
+	parts := make([]string, 0)
+	previous := ""
+	for col := startCol; col < endCol && col < len(row); col++ {
+		cell := s.cleanValue(row[col])
+		if cell == "" || cell == previous {
+			continue
+		}
+		previous = cell
+
+		// Аудитория обычно короткая: "305", "с/з"
+		if len([]rune(cell)) <= 5 && len(parts) > 0 {
+			classroom = cell
+			continue
+		}
+		parts = append(parts, cell)
+	}
+	return strings.Join(parts, " "), classroom
+}
+
+var (
+	examKindPattern      = regexp.MustCompile(`(?i)\(?\s*(дифференцированный\s+зач[её]т|дифф?\.?\s*зач[её]т|зач[её]т\s+с\s+оценкой|консультация|консульт\.|зач[её]т|экзамен|экз\.)\s*\)?\s*:?`)
+	examClassroomPattern = regexp.MustCompile(`(?i)ауд\.?\s*(\S+)`)
+	examTimePattern      = regexp.MustCompile(`\d{1,2}[.:]\d{2}`)
+	examTeacherPattern   = regexp.MustCompile(`[А-ЯЁ][а-яё]+\s+[А-ЯЁ]\.\s*[А-ЯЁ]\.?`)
+)
+
+// parseExamCell разбирает описание мероприятия:
+// "Экзамен Математика Гареева Г.А. ауд. 305" или "Математика (консультация) Гареева Г.А."
+func (s *ParserService) parseExamCell(text string) models.Exam {
+	var exam models.Exam
+	exam.Kind, text = extractExamKind(text)
+
+	if matches := examClassroomPattern.FindStringSubmatch(text); len(matches) > 1 {
+		exam.Classroom = strings.TrimRight(matches[1], ".,;")
+		text = strings.Replace(text, matches[0], "", 1)
+	}
+
+	if teacher := examTeacherPattern.FindString(text); teacher != "" {
+		exam.Teacher, exam.TeacherID = normalizeTeacher(teacher)
+		text = strings.Replace(text, teacher, "", 1)
+	}
 
-	return json.MarshalIndent(schedule, "", "  ")
+	exam.Subject = strings.Trim(strings.Join(strings.Fields(text), " "), " ,;-–")
+	return exam
+}
+
+// extractExamKind определяет вид мероприятия по ключевому слову и убирает его из текста
+func extractExamKind(text string) (kind, rest string) {
+	match := examKindPattern.FindStringSubmatch(text)
+	if len(match) < 2 {
+		return "", text
+	}
+
+	word := strings.ReplaceAll(strings.ToLower(match[1]), "ё", "е")
+	switch {
+	case strings.HasPrefix(word, "консульт"):
+		kind = models.ExamKindConsultation
+	case strings.HasPrefix(word, "диф"), strings.Contains(word, "оценк"):
+		kind = models.ExamKindGradedCredit
+	case strings.HasPrefix(word, "зачет"):
+		kind = models.ExamKindCredit
+	default:
+		kind = models.ExamKindExam
+	}
+
+	rest = strings.Replace(text, match[0], " ", 1)
+	return kind, strings.Trim(strings.Join(strings.Fields(rest), " "), " ,;-–")
+}
+
+var (
+	dateRangePattern = regexp.MustCompile(`(\d{1,2})(?:\.(\d{1,2}))?(?:\.(\d{4}|\d{2}))?\s*[-–—]\s*(\d{1,2})\.(\d{1,2})\.(\d{4}|\d{2})`)
+	datePattern      = regexp.MustCompile(`(\d{1,2})\.(\d{1,2})\.(\d{4}|\d{2})`)
+	isoDatePattern   = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	excelDatePattern = regexp.MustCompile(`^(\d{2})-(\d{2})-(\d{2})$`) // встроенный формат Excel mm-dd-yy
+	monthNamePattern = regexp.MustCompile(`(?i)(\d{1,2})\s+(январ|феврал|март|апрел|ма|июн|июл|август|сентябр|октябр|ноябр|декабр)[а-я]*\s+(\d{4})`)
+)
+
+// monthStems — основы названий месяцев в порядке календаря
+var monthStems = []string{"январ", "феврал", "март", "апрел", "ма", "июн", "июл", "август", "сентябр", "октябр", "ноябр", "декабр"}
+
+// parseDateCell разбирает дату ("ПН 12.01.2026", "12.01.26", "12 января 2026") или диапазон дней
+// ("12-14.01.2026", "30.12.2025 - 10.01.2026") и возвращает даты в формате YYYY-MM-DD
+func parseDateCell(cell string) (date, endDate string) {
+	if m := dateRangePattern.FindStringSubmatch(cell); m != nil {
+		endDate = buildDate(m[4], m[5], m[6])
+		month, year := m[2], m[3]
+		if month == "" {
+			month = m[5]
+		}
+		if year == "" {
+			year = m[6]
+			// Диапазон через Новый год: "29.12-10.01.2026"
+			if atoi(month) > atoi(m[5]) {
+				year = strconv.Itoa(atoi(fullYear(year)) - 1)
+			}
+		}
+		date = buildDate(m[1], month, year)
+		if date != "" && endDate != "" && date < endDate {
+			return date, endDate
+		}
+		if endDate != "" {
+			return endDate, ""
+		}
+	}
+	if m := datePattern.FindStringSubmatch(cell); m != nil {
+		if date = buildDate(m[1], m[2], m[3]); date != "" {
+			return date, ""
+		}
+	}
+	if m := isoDatePattern.FindStringSubmatch(cell); m != nil {
+		return buildDate(m[3], m[2], m[1]), ""
+	}
+	if m := monthNamePattern.FindStringSubmatch(cell); m != nil {
+		month := slices.Index(monthStems, strings.ToLower(m[2])) + 1
+		return buildDate(m[1], strconv.Itoa(month), m[3]), ""
+	}
+	if m := excelDatePattern.FindStringSubmatch(cell); m != nil {
+		return buildDate(m[2], m[1], m[3]), ""
+	}
+	return "", ""
+}
+
+// buildDate собирает дату YYYY-MM-DD; для несуществующей даты возвращает пустую строку
+func buildDate(day, month, year string) string {
+	d, m, y := atoi(day), atoi(month), atoi(fullYear(year))
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Day() != d || int(t.Month()) != m {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func fullYear(year string) string {
+	if len(year) == 2 {
+		return "20" + year
+	}
+	return year
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+func isISODate(value string) bool {
+	return isoDatePattern.MatchString(value)
+}
+
+// fillMergedCells копирует значение каждой объединённой ячейки во все ячейки её диапазона
+func (s *ParserService) fillMergedCells(f *excelize.File, sheet string, rows [][]string) ([][]string, error) {
+	merged, err := f.GetMergeCells(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cell := range merged {
+		startCol, startRow, err := excelize.CellNameToCoordinates(cell.GetStartAxis())
+		if err != nil {
+			continue
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(cell.GetEndAxis())
+		if err != nil {
+			continue
+		}
+		value := cell.GetCellValue()
+		if value == "" {
+			continue
+		}
+
+		for r := startRow - 1; r < endRow; r++ {
+			for len(rows) <= r {
+				rows = append(rows, nil)
+			}
+			for len(rows[r]) < endCol {
+				rows[r] = append(rows[r], "")
+			}
+			for c := startCol - 1; c < endCol; c++ {
+				rows[r][c] = value
+			}
+		}
+	}
+	return rows, nil
 }
 
 // ValidateScheduleFile валидирует структуру
-func (s *ParserService) ValidateScheduleFile(file io.Reader, scheduleType string) (bool, error) {
+func (s *ParserService) ValidateScheduleFile(ctx context.Context, file io.Reader, scheduleType string) (_ bool, err error) {
+	_, span := tracing.Start(ctx, "parser.ValidateScheduleFile", trace.WithAttributes(attribute.String("schedule.type", scheduleType)))
+	defer func() { tracing.End(span, err) }()
+
 	f, err := excelize.OpenReader(file)
 	if err != nil {
-		return false, fmt.Errorf("invalid xlsx file: %w", err)
+		return false, fmt.Errorf("%w: %w", ErrInvalidXLSX, err)
 	}
 	defer f.Close()
 
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
-		return false, fmt.Errorf("no sheets found")
+		return false, ErrNoSheets
 	}
 
 	rows, err := f.GetRows(sheets[0])
@@ -518,7 +1086,7 @@ func (s *ParserService) ValidateScheduleFile(file io.Reader, scheduleType string
 	}
 
 	if len(rows) < 5 {
-		return false, fmt.Errorf("file must contain at least 5 rows")
+		return false, fmt.Errorf("%w: need at least 5 rows, got %d", ErrTooFewRows, len(rows))
 	}
 
 	return true, nil
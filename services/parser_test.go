@@ -0,0 +1,439 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"schedule-api/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// lessonSummary — поля занятия, которые проверяют тесты разбора ячеек
+type lessonSummary struct {
+	Subject   string
+	Type      string
+	SubGroup  string
+	Teachers  []string
+	Classroom string
+}
+
+func summarizeLessons(lessons []models.Lesson) []lessonSummary {
+	result := make([]lessonSummary, 0, len(lessons))
+	for _, lesson := range lessons {
+		result = append(result, lessonSummary{
+			Subject:   lesson.Subject,
+			Type:      lesson.Type,
+			SubGroup:  lesson.SubGroup,
+			Teachers:  lesson.AllTeachers(),
+			Classroom: lesson.Classroom,
+		})
+	}
+	return result
+}
+
+func equalLessons(a, b []lessonSummary) bool {
+	return slices.EqualFunc(a, b, func(x, y lessonSummary) bool {
+		return x.Subject == y.Subject && x.Type == y.Type && x.SubGroup == y.SubGroup &&
+			x.Classroom == y.Classroom && slices.Equal(x.Teachers, y.Teachers)
+	})
+}
+
+func TestParseDiscipline(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []lessonSummary
+	}{
+		{
+			name: "one teacher",
+			text: "Математика (пр.) Гареева Г.А.",
+			want: []lessonSummary{{Subject: "Математика", Type: "пр.", Teachers: []string{"Гареева Г.А."}}},
+		},
+		{
+			name: "teacher spelling is normalized",
+			text: "Математика (лек.) Гареева  Г. А",
+			want: []lessonSummary{{Subject: "Математика", Type: "лек.", Teachers: []string{"Гареева Г.А."}}},
+		},
+		{
+			name: "co-taught lesson keeps all teachers",
+			text: "Физика (лаб.) Иванов И.И., Петров П.П.",
+			want: []lessonSummary{{Subject: "Физика", Type: "лаб.", Teachers: []string{"Иванов И.И.", "Петров П.П."}}},
+		},
+		{
+			name: "subgroups with own teachers",
+			text: "Иностранный язык (пр.) 1п/г Буланова Л.Н. 2п/г Петров И.И.",
+			want: []lessonSummary{
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "1п/г", Teachers: []string{"Буланова Л.Н."}},
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "2п/г", Teachers: []string{"Петров И.И."}},
+			},
+		},
+		{
+			name: "subgroups with own classrooms",
+			text: "Информатика (лаб.) 1 п/г Иванов И.И. ауд. 305 2 п/г Петров П.П. ауд. 307",
+			want: []lessonSummary{
+				{Subject: "Информатика", Type: "лаб.", SubGroup: "1 п/г", Teachers: []string{"Иванов И.И."}, Classroom: "305"},
+				{Subject: "Информатика", Type: "лаб.", SubGroup: "2 п/г", Teachers: []string{"Петров П.П."}, Classroom: "307"},
+			},
+		},
+		{
+			name: "teacher before the only subgroup marker",
+			text: "Иностранный язык (пр.) Буланова Л.Н. 1п/г",
+			want: []lessonSummary{{Subject: "Иностранный язык", Type: "пр.", SubGroup: "1п/г", Teachers: []string{"Буланова Л.Н."}}},
+		},
+		{
+			name: "description in parentheses is dropped",
+			text: "Физическая культура и спорт (элективная дисциплина) Телешев С.А.",
+			want: []lessonSummary{{Subject: "Физическая культура и спорт", Teachers: []string{"Телешев С.А."}}},
+		},
+	}
+
+	parser := NewParserService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeLessons(parser.parseDiscipline(tt.text))
+			if !equalLessons(got, tt.want) {
+				t.Errorf("parseDiscipline(%q)\n got: %+v\nwant: %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDisciplineTeacherIDs(t *testing.T) {
+	parser := NewParserService()
+	lessons := parser.parseDiscipline("Физика (лаб.) Иванов И.И., Петров П.П.")
+	if len(lessons) != 1 {
+		t.Fatalf("got %d lessons, want 1", len(lessons))
+	}
+
+	lesson := lessons[0]
+	want := []string{models.TeacherID("Иванов И.И."), models.TeacherID("Петров П.П.")}
+	if !slices.Equal(lesson.TeacherIDs, want) {
+		t.Errorf("TeacherIDs = %v, want %v", lesson.TeacherIDs, want)
+	}
+	if lesson.Teacher != "Иванов И.И." || lesson.TeacherID != want[0] {
+		t.Errorf("first teacher = %q (%s), want Иванов И.И. (%s)", lesson.Teacher, lesson.TeacherID, want[0])
+	}
+}
+
+func TestParseLessonsClassrooms(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []string
+		want []lessonSummary
+	}{
+		{
+			name: "classroom list is split between subgroups",
+			row:  []string{"Иностранный язык (пр.) 1п/г Буланова Л.Н. 2п/г Петров И.И.", "305/307"},
+			want: []lessonSummary{
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "1п/г", Teachers: []string{"Буланова Л.Н."}, Classroom: "305"},
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "2п/г", Teachers: []string{"Петров И.И."}, Classroom: "307"},
+			},
+		},
+		{
+			name: "classroom columns per subgroup",
+			row:  []string{"Иностранный язык (пр.) 1п/г Буланова Л.Н. 2п/г Петров И.И.", "305", "307"},
+			want: []lessonSummary{
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "1п/г", Teachers: []string{"Буланова Л.Н."}, Classroom: "305"},
+				{Subject: "Иностранный язык", Type: "пр.", SubGroup: "2п/г", Teachers: []string{"Петров И.И."}, Classroom: "307"},
+			},
+		},
+		{
+			name: "one classroom for a co-taught lesson",
+			row:  []string{"Физика (лаб.) Иванов И.И., Петров П.П.", "с/з"},
+			want: []lessonSummary{{Subject: "Физика", Type: "лаб.", Teachers: []string{"Иванов И.И.", "Петров П.П."}, Classroom: "с/з"}},
+		},
+	}
+
+	parser := NewParserService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lessons := parser.parseLessons(tt.row, 0, len(tt.row), "8.30-10.00")
+			for _, lesson := range lessons {
+				if lesson.Time != "8.30-10.00" {
+					t.Errorf("lesson time = %q, want 8.30-10.00", lesson.Time)
+				}
+			}
+			if got := summarizeLessons(lessons); !equalLessons(got, tt.want) {
+				t.Errorf("parseLessons(%q)\n got: %+v\nwant: %+v", tt.row, got, tt.want)
+			}
+		})
+	}
+}
+
+// newWorkbook создаёт книгу с одним листом из строк; merges — диапазоны объединённых ячеек ("A3:I3")
+func newWorkbook(t *testing.T, rows [][]string, merges ...string) *excelize.File {
+	t.Helper()
+
+	f := excelize.NewFile()
+	t.Cleanup(func() { f.Close() })
+	sheet := f.GetSheetList()[0]
+	for i, row := range rows {
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			t.Fatalf("failed to fill row %d: %v", i+1, err)
+		}
+	}
+	for _, merge := range merges {
+		start, end, _ := strings.Cut(merge, ":")
+		if err := f.MergeCell(sheet, start, end); err != nil {
+			t.Fatalf("failed to merge %s: %v", merge, err)
+		}
+	}
+	return f
+}
+
+var replacementHeader = []string{"Дата", "Группа", "Пара", "Дисциплина по расписанию", "Замена", "Преподаватель по расписанию", "Новый преподаватель", "Аудитория", "Причина"}
+
+func TestParseReplacementScheduleDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       [][]string
+		merges     []string
+		wantDate   string
+		wantDates  []string
+		wantGroups []string
+		wantRows   []string // Дата и группа каждой замены: "2026-01-12 23101"
+	}{
+		{
+			name: "date and group columns",
+			rows: [][]string{
+				replacementHeader,
+				{"13.01.2026", "23102", "2", "Физика", "Химия", "Иванов И.И.", "Петров П.П.", "305"},
+				{"12.01.2026", "23101", "1", "Математика", "История", "Гареева Г.А.", "Сидоров С.С.", "210"},
+			},
+			wantDate:   "2026-01-12",
+			wantDates:  []string{"2026-01-12", "2026-01-13"},
+			wantGroups: []string{"23102", "23101"},
+			wantRows:   []string{"2026-01-13 23102", "2026-01-12 23101"},
+		},
+		{
+			name: "date from title and group separator rows",
+			rows: [][]string{
+				{"Замены на 12.01.2026"},
+				replacementHeader[2:],
+				{"Группа 23101"},
+				{"1", "Математика", "История", "Гареева Г.А.", "Сидоров С.С.", "210"},
+				{"Группа 23102"},
+				{"2", "Физика", "Химия", "Иванов И.И.", "Петров П.П.", "305"},
+			},
+			wantDate:   "2026-01-12",
+			wantDates:  []string{"2026-01-12"},
+			wantGroups: []string{"23101", "23102"},
+			wantRows:   []string{"2026-01-12 23101", "2026-01-12 23102"},
+		},
+		{
+			name: "merged day separators",
+			rows: [][]string{
+				replacementHeader[1:],
+				{"12 января 2026"},
+				{"23101", "1", "Математика", "История", "Гареева Г.А.", "Сидоров С.С.", "210"},
+				{"14.01.2026"},
+				{"23101", "3", "Физика", "Химия", "Иванов И.И.", "Петров П.П.", "305"},
+			},
+			merges:     []string{"A2:H2", "A4:H4"},
+			wantDate:   "2026-01-12",
+			wantDates:  []string{"2026-01-12", "2026-01-14"},
+			wantGroups: []string{"23101"},
+			wantRows:   []string{"2026-01-12 23101", "2026-01-14 23101"},
+		},
+		{
+			name: "legacy format without header",
+			rows: [][]string{
+				{"Замены на 15.01.2026"},
+				{},
+				{"1", "Математика", "История", "Гареева Г.А.", "Сидоров С.С.", "210"},
+			},
+			wantDate:  "2026-01-15",
+			wantDates: []string{"2026-01-15"},
+			wantRows:  []string{"2026-01-15 "},
+		},
+	}
+
+	parser := NewParserService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parser.parseReplacementSchedule(newWorkbook(t, tt.rows, tt.merges...))
+			if err != nil {
+				t.Fatalf("parseReplacementSchedule: %v", err)
+			}
+
+			if schedule.Date != tt.wantDate {
+				t.Errorf("Date = %q, want %q", schedule.Date, tt.wantDate)
+			}
+			if !slices.Equal(schedule.Dates, tt.wantDates) {
+				t.Errorf("Dates = %v, want %v", schedule.Dates, tt.wantDates)
+			}
+			if !slices.Equal(schedule.Groups, tt.wantGroups) {
+				t.Errorf("Groups = %v, want %v", schedule.Groups, tt.wantGroups)
+			}
+			got := make([]string, 0, len(schedule.Replacements))
+			for _, r := range schedule.Replacements {
+				got = append(got, r.Date+" "+r.Group)
+			}
+			if !slices.Equal(got, tt.wantRows) {
+				t.Errorf("replacements = %q, want %q", got, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestParseReplacementScheduleStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		row        []string
+		wantStatus string
+		wantReason string
+		wantNew    string
+	}{
+		{
+			name:       "replaced",
+			row:        []string{"12.01.2026", "23101", "1", "Математика", "История", "Гареева Г.А.", "Сидоров С.С.", "210"},
+			wantStatus: models.ReplacementStatusReplaced,
+			wantNew:    "История",
+		},
+		{
+			name:       "cancelled with reason in parentheses",
+			row:        []string{"12.01.2026", "23101", "1", "Математика", "Отмена (болезнь преподавателя)", "Гареева Г.А."},
+			wantStatus: models.ReplacementStatusCancelled,
+			wantReason: "болезнь преподавателя",
+		},
+		{
+			name:       "dash instead of replacement",
+			row:        []string{"12.01.2026", "23101", "1", "Математика", "—", "Гареева Г.А.", "", "", "Курсы повышения квалификации"},
+			wantStatus: models.ReplacementStatusCancelled,
+			wantReason: "Курсы повышения квалификации",
+		},
+	}
+
+	parser := NewParserService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parser.parseReplacementSchedule(newWorkbook(t, [][]string{replacementHeader, tt.row}))
+			if err != nil {
+				t.Fatalf("parseReplacementSchedule: %v", err)
+			}
+			if len(schedule.Replacements) != 1 {
+				t.Fatalf("got %d replacements, want 1", len(schedule.Replacements))
+			}
+
+			r := schedule.Replacements[0]
+			if r.Status != tt.wantStatus || r.Reason != tt.wantReason || r.NewSubject != tt.wantNew {
+				t.Errorf("status, reason, newSubject = %q, %q, %q; want %q, %q, %q",
+					r.Status, r.Reason, r.NewSubject, tt.wantStatus, tt.wantReason, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestParseDateCell(t *testing.T) {
+	tests := []struct {
+		cell        string
+		wantDate    string
+		wantEndDate string
+	}{
+		{"ПН 12.01.2026", "2026-01-12", ""},
+		{"12.01.26", "2026-01-12", ""},
+		{"12 января 2026", "2026-01-12", ""},
+		{"2026-01-12", "2026-01-12", ""},
+		{"01-12-26", "2026-01-12", ""},
+		{"12-14.01.2026", "2026-01-12", "2026-01-14"},
+		{"30.01-02.02.2026", "2026-01-30", "2026-02-02"},
+		{"29.12-10.01.2026", "2025-12-29", "2026-01-10"},
+		{"30.12.2025 - 10.01.2026", "2025-12-30", "2026-01-10"},
+		{"31.02.2026", "", ""},
+		{"консультация", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			date, endDate := parseDateCell(tt.cell)
+			if date != tt.wantDate || endDate != tt.wantEndDate {
+				t.Errorf("parseDateCell(%q) = %q, %q; want %q, %q", tt.cell, date, endDate, tt.wantDate, tt.wantEndDate)
+			}
+		})
+	}
+}
+
+func TestParseExamSchedule(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       [][]string
+		merges     []string
+		wantStart  string
+		wantEnd    string
+		wantGroups []string
+		want       []models.Exam
+	}{
+		{
+			name: "group columns with ranges and merged cells",
+			rows: [][]string{
+				{"Расписание экзаменационной сессии"},
+				{"Дата", "Время", "23101", "", "23102", ""},
+				{"29.12-10.01.2026", "9.00", "Консультация Математика", "", "", ""},
+				{"12.01.2026", "9.00", "Экзамен Математика Гареева Г. А.", "305", "Зачет с оценкой Физика Иванов И.И.", "с/з"},
+				{"13-14.01.2026", "", "", "", "Дифф. зачет Химия ауд. 210", ""},
+			},
+			merges:     []string{"C3:F3"},
+			wantStart:  "2025-12-29",
+			wantEnd:    "2026-01-14",
+			wantGroups: []string{"23101", "23102"},
+			want: []models.Exam{
+				{Date: "2025-12-29", EndDate: "2026-01-10", Time: "9.00", Group: "23101", Kind: models.ExamKindConsultation, Subject: "Математика"},
+				{Date: "2025-12-29", EndDate: "2026-01-10", Time: "9.00", Group: "23102", Kind: models.ExamKindConsultation, Subject: "Математика"},
+				{Date: "2026-01-12", Time: "9.00", Group: "23101", Kind: models.ExamKindExam, Subject: "Математика", Teacher: "Гареева Г.А.", Classroom: "305"},
+				{Date: "2026-01-12", Time: "9.00", Group: "23102", Kind: models.ExamKindGradedCredit, Subject: "Физика", Teacher: "Иванов И.И.", Classroom: "с/з"},
+				{Date: "2026-01-13", EndDate: "2026-01-14", Group: "23102", Kind: models.ExamKindGradedCredit, Subject: "Химия", Classroom: "210"},
+			},
+		},
+		{
+			name: "legacy format without groups",
+			rows: [][]string{
+				{"Расписание экзаменов"},
+				{"Дата", "Время", "Дисциплина", "Преподаватель", "Аудитория"},
+				{"12.01.2026", "9.00", "Экзамен: Математика", "Гареева  Г.А.", "305"},
+				{"", "13.00", "Зачет: Физика", "Иванов И.И.", "307"},
+			},
+			merges:    []string{"A3:A4"},
+			wantStart: "2026-01-12",
+			wantEnd:   "2026-01-12",
+			want: []models.Exam{
+				{Date: "2026-01-12", Time: "9.00", Kind: models.ExamKindExam, Subject: "Математика", Teacher: "Гареева Г.А.", Classroom: "305"},
+				{Date: "2026-01-12", Time: "13.00", Kind: models.ExamKindCredit, Subject: "Физика", Teacher: "Иванов И.И.", Classroom: "307"},
+			},
+		},
+	}
+
+	parser := NewParserService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parser.parseExamSchedule(newWorkbook(t, tt.rows, tt.merges...))
+			if err != nil {
+				t.Fatalf("parseExamSchedule: %v", err)
+			}
+
+			if schedule.StartDate != tt.wantStart || schedule.EndDate != tt.wantEnd {
+				t.Errorf("session = %s..%s, want %s..%s", schedule.StartDate, schedule.EndDate, tt.wantStart, tt.wantEnd)
+			}
+			if !slices.Equal(schedule.Groups, tt.wantGroups) {
+				t.Errorf("Groups = %v, want %v", schedule.Groups, tt.wantGroups)
+			}
+			if len(schedule.Exams) != len(tt.want) {
+				t.Fatalf("got %d exams, want %d: %+v", len(schedule.Exams), len(tt.want), schedule.Exams)
+			}
+			for i, want := range tt.want {
+				if want.Teacher != "" {
+					want.TeacherID = models.TeacherID(want.Teacher)
+				}
+				if got := schedule.Exams[i]; got != want {
+					t.Errorf("exam %d\n got: %+v\nwant: %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
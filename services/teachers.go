@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"schedule-api/config"
+	"schedule-api/models"
+)
+
+// normalizeTeacher нормализует имя преподавателя из ячейки и возвращает его ID.
+// Прочерк вместо имени оставляется как есть и ID не получает
+func normalizeTeacher(name string) (string, string) {
+	name = models.NormalizeTeacherName(name)
+	if !strings.ContainsFunc(name, unicode.IsLetter) {
+		return name, ""
+	}
+	return name, models.TeacherID(name)
+}
+
+// ExamTeachers возвращает преподавателей экзаменационного расписания без повторов
+func ExamTeachers(schedule *models.ExamSchedule) []string {
+	teachers := make([]string, 0)
+	for _, exam := range schedule.Exams {
+		if exam.TeacherID != "" && !slices.Contains(teachers, exam.Teacher) {
+			teachers = append(teachers, exam.Teacher)
+		}
+	}
+	return teachers
+}
+
+// ReplacementTeachers возвращает преподавателей расписания замен без повторов
+func ReplacementTeachers(schedule *models.ReplacementSchedule) []string {
+	teachers := make([]string, 0)
+	for _, replacement := range schedule.Replacements {
+		if replacement.OriginalTeacherID != "" && !slices.Contains(teachers, replacement.OriginalTeacher) {
+			teachers = append(teachers, replacement.OriginalTeacher)
+		}
+		if replacement.NewTeacherID != "" && !slices.Contains(teachers, replacement.NewTeacher) {
+			teachers = append(teachers, replacement.NewTeacher)
+		}
+	}
+	return teachers
+}
+
+// ScheduleTeachers возвращает преподавателей основного расписания без повторов
+func ScheduleTeachers(schedule *models.RegularSchedule) []string {
+	teachers := make([]string, 0)
+	for _, group := range schedule.Groups {
+		for _, day := range group.Days {
+			for _, lesson := range day.Lessons {
+				for _, teacher := range lesson.AllTeachers() {
+					if !slices.Contains(teachers, teacher) {
+						teachers = append(teachers, teacher)
+					}
+				}
+			}
+		}
+	}
+	return teachers
+}
+
+// TeacherDirectory — справочник преподавателей из всех обработанных файлов
+// с ФИО и кафедрами из сопоставления администратора. Хранится JSON-объектом в MinIO
+type TeacherDirectory struct {
+	minio  *MinIOService
+	bucket string
+	object string
+
+	mu       sync.Mutex
+	loaded   bool
+	teachers []models.Teacher // По имени
+}
+
+func NewTeacherDirectory(minio *MinIOService, cfg *config.Config) *TeacherDirectory {
+	return &TeacherDirectory{
+		minio:  minio,
+		bucket: cfg.MinIOBucket,
+		object: cfg.TeachersObject,
+	}
+}
+
+// Load читает справочник; отсутствие объекта означает пустой справочник.
+// Если при запуске MinIO недоступен, загрузка повторяется при следующем обращении
+func (d *TeacherDirectory) Load(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.load(ctx)
+}
+
+// load вызывается под d.mu
+func (d *TeacherDirectory) load(ctx context.Context) error {
+	info, err := d.minio.StatObjectInBucket(ctx, d.bucket, d.object)
+	if err != nil {
+		return fmt.Errorf("failed to check teacher directory: %w", err)
+	}
+	if info == nil {
+		d.loaded = true
+		return nil
+	}
+
+	data, err := d.minio.DownloadFile(ctx, d.bucket, d.object)
+	if err != nil {
+		return fmt.Errorf("failed to download teacher directory: %w", err)
+	}
+	var teachers []models.Teacher
+	if err := json.Unmarshal(data, &teachers); err != nil {
+		return fmt.Errorf("invalid teacher directory object %s: %w", d.object, err)
+	}
+
+	d.teachers = teachers
+	d.loaded = true
+	return nil
+}
+
+// ensureLoaded догружает справочник, если это не удалось при запуске; вызывается под d.mu
+func (d *TeacherDirectory) ensureLoaded(ctx context.Context) error {
+	if d.loaded {
+		return nil
+	}
+	return d.load(ctx)
+}
+
+// Teachers возвращает всех преподавателей справочника
+func (d *TeacherDirectory) Teachers(ctx context.Context) ([]models.Teacher, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	return slices.Clone(d.teachers), nil
+}
+
+// Teacher возвращает преподавателя по ID или nil, если его нет
+func (d *TeacherDirectory) Teacher(ctx context.Context, id string) (*models.Teacher, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	if i := indexTeacher(d.teachers, id); i >= 0 {
+		teacher := d.teachers[i]
+		return &teacher, nil
+	}
+	return nil, nil
+}
+
+// Register добавляет в справочник преподавателей из обработанного файла.
+// Справочник сохраняется, только если появились новые преподаватели или написания
+func (d *TeacherDirectory) Register(ctx context.Context, names []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ensureLoaded(ctx); err != nil {
+		return err
+	}
+
+	next := cloneTeachers(d.teachers)
+	changed := false
+	for _, name := range names {
+		if addTeacher(&next, models.NormalizeTeacherName(name)) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	sortTeachers(next)
+	if err := d.save(ctx, next); err != nil {
+		return err
+	}
+	d.teachers = next
+	return nil
+}
+
+// SetMapping заменяет ФИО и кафедры преподавателей загруженным сопоставлением.
+// Преподаватели из сопоставления, ещё не встречавшиеся в файлах, добавляются в справочник.
+// Если в сопоставлении есть неизвестные ID, справочник не меняется и возвращаются эти ID
+func (d *TeacherDirectory) SetMapping(ctx context.Context, mapping []models.TeacherMapping) ([]models.Teacher, []string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.ensureLoaded(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	next := cloneTeachers(d.teachers)
+	for i := range next {
+		next[i].FullName, next[i].Department = "", ""
+	}
+
+	unknown := make([]string, 0)
+	for _, m := range mapping {
+		id := m.ID
+		if id == "" {
+			name := models.NormalizeTeacherName(m.Name)
+			addTeacher(&next, name)
+			id = models.TeacherID(name)
+		}
+		i := indexTeacher(next, id)
+		if i < 0 {
+			unknown = append(unknown, id)
+			continue
+		}
+		next[i].FullName = strings.Join(strings.Fields(m.FullName), " ")
+		next[i].Department = strings.Join(strings.Fields(m.Department), " ")
+	}
+	if len(unknown) > 0 {
+		return nil, unknown, nil
+	}
+
+	sortTeachers(next)
+	if err := d.save(ctx, next); err != nil {
+		return nil, nil, err
+	}
+	d.teachers = next
+	return slices.Clone(next), nil, nil
+}
+
+// save записывает справочник в MinIO; вызывается под d.mu
+func (d *TeacherDirectory) save(ctx context.Context, teachers []models.Teacher) error {
+	data, err := json.MarshalIndent(teachers, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode teacher directory: %w", err)
+	}
+	if _, err := d.minio.UploadFile(ctx, d.bucket, d.object, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return fmt.Errorf("failed to save teacher directory: %w", err)
+	}
+	return nil
+}
+
+// addTeacher добавляет нормализованное имя в справочник или новое написание к существующей записи.
+// Написание с Ё считается точнее и становится основным. Возвращает true, если справочник изменился
+func addTeacher(teachers *[]models.Teacher, name string) bool {
+	id := models.TeacherID(name)
+	if id == "" {
+		return false
+	}
+
+	i := indexTeacher(*teachers, id)
+	if i < 0 {
+		*teachers = append(*teachers, models.Teacher{ID: id, Name: name})
+		return true
+	}
+
+	teacher := &(*teachers)[i]
+	if teacher.Name == name || slices.Contains(teacher.Variants, name) {
+		return false
+	}
+	if strings.ContainsAny(name, "Ёё") && !strings.ContainsAny(teacher.Name, "Ёё") {
+		teacher.Variants = append(teacher.Variants, teacher.Name)
+		teacher.Name = name
+	} else {
+		teacher.Variants = append(teacher.Variants, name)
+	}
+	return true
+}
+
+func sortTeachers(teachers []models.Teacher) {
+	slices.SortFunc(teachers, func(a, b models.Teacher) int { return strings.Compare(a.Name, b.Name) })
+}
+
+func indexTeacher(teachers []models.Teacher, id string) int {
+	return slices.IndexFunc(teachers, func(t models.Teacher) bool { return t.ID == id })
+}
+
+// cloneTeachers копирует справочник вместе со списками написаний
+func cloneTeachers(teachers []models.Teacher) []models.Teacher {
+	next := slices.Clone(teachers)
+	for i := range next {
+		next[i].Variants = slices.Clone(next[i].Variants)
+	}
+	return next
+}
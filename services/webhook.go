@@ -0,0 +1,472 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/metrics"
+	"schedule-api/models"
+)
+
+// Заголовки запроса webhook'а. Подпись — HMAC-SHA256 от "<timestamp>.<тело>" ключом подписки
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+)
+
+const (
+	webhookLogSize    = 1000 // Сколько последних доставок хранится в журнале
+	webhookMaxBackoff = 30 * time.Minute
+)
+
+// WebhookService хранит подписки и доставляет уведомления об изменениях расписаний.
+// Подписки сохраняются JSON-объектом в MinIO; журнал доставок и dead-letter живут в памяти
+type WebhookService struct {
+	minio       *MinIOService
+	bucket      string
+	object      string
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+
+	mu            sync.Mutex
+	loaded        bool
+	subscriptions []models.WebhookSubscription
+	deliveries    []*models.WebhookDelivery // Журнал, от старых к новым
+	deadLetters   []*models.WebhookDelivery
+
+	slots   chan struct{} // Ограничение одновременных запросов
+	stop    chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func NewWebhookService(minio *MinIOService, cfg *config.Config) *WebhookService {
+	return &WebhookService{
+		minio:       minio,
+		bucket:      cfg.MinIOBucket,
+		object:      cfg.WebhooksObject,
+		client:      &http.Client{Timeout: cfg.WebhookTimeout},
+		maxAttempts: cfg.WebhookMaxAttempts,
+		retryBase:   cfg.WebhookRetryBase,
+		slots:       make(chan struct{}, cfg.WebhookConcurrency),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Load читает сохранённые подписки; отсутствие объекта означает, что подписок нет.
+// Если при запуске MinIO недоступен, загрузка повторяется при следующем обращении к подпискам
+func (s *WebhookService) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(ctx)
+}
+
+// load вызывается под s.mu
+func (s *WebhookService) load(ctx context.Context) error {
+	info, err := s.minio.StatObjectInBucket(ctx, s.bucket, s.object)
+	if err != nil {
+		return fmt.Errorf("failed to check webhook subscriptions: %w", err)
+	}
+	if info == nil {
+		s.loaded = true
+		return nil
+	}
+
+	data, err := s.minio.DownloadFile(ctx, s.bucket, s.object)
+	if err != nil {
+		return fmt.Errorf("failed to download webhook subscriptions: %w", err)
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return fmt.Errorf("invalid webhook subscriptions object %s: %w", s.object, err)
+	}
+
+	s.subscriptions = subscriptions
+	s.loaded = true
+	return nil
+}
+
+// ensureLoaded догружает подписки, если это не удалось при запуске; вызывается под s.mu
+func (s *WebhookService) ensureLoaded(ctx context.Context) error {
+	if s.loaded {
+		return nil
+	}
+	return s.load(ctx)
+}
+
+// Subscriptions возвращает подписки без секретов
+func (s *WebhookService) Subscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]models.WebhookSubscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		sub.Secret = ""
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+// Subscribe добавляет подписку. Если секрет не задан, он генерируется;
+// возвращённая подписка содержит секрет — это единственный раз, когда он виден клиенту
+func (s *WebhookService) Subscribe(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	sub.ID = newID()
+	sub.CreatedAt = time.Now().UTC()
+	if sub.Secret == "" {
+		secret := make([]byte, 32)
+		_, _ = rand.Read(secret)
+		sub.Secret = hex.EncodeToString(secret)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureLoaded(ctx); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	next := append(append([]models.WebhookSubscription(nil), s.subscriptions...), sub)
+	if err := s.save(ctx, next); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	s.subscriptions = next
+	return sub, nil
+}
+
+// Unsubscribe удаляет подписку. Возвращает false, если подписки нет
+func (s *WebhookService) Unsubscribe(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureLoaded(ctx); err != nil {
+		return false, err
+	}
+
+	next := make([]models.WebhookSubscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		if sub.ID != id {
+			next = append(next, sub)
+		}
+	}
+	if len(next) == len(s.subscriptions) {
+		return false, nil
+	}
+
+	if err := s.save(ctx, next); err != nil {
+		return false, err
+	}
+	s.subscriptions = next
+	return true, nil
+}
+
+// save записывает подписки в MinIO; вызывается под s.mu
+func (s *WebhookService) save(ctx context.Context, subscriptions []models.WebhookSubscription) error {
+	data, err := json.MarshalIndent(subscriptions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook subscriptions: %w", err)
+	}
+	if _, err := s.minio.UploadFile(ctx, s.bucket, s.object, bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return fmt.Errorf("failed to save webhook subscriptions: %w", err)
+	}
+	return nil
+}
+
+// Notify ставит в очередь уведомления всем подписчикам, которых касается изменение.
+// Подписки на группу или преподавателя получают только свои занятия и только если они изменились;
+// для замен и экзаменов, где diff не строится, — если группа или преподаватель есть в файле
+func (s *WebhookService) Notify(ctx context.Context, payload models.WebhookPayload) error {
+	if payload.Diff != nil && payload.Diff.Summary.Empty() {
+		return nil
+	}
+	if payload.Event == "" {
+		payload.Event = models.WebhookEventScheduleChanged
+	}
+	if payload.Time.IsZero() {
+		payload.Time = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil
+	}
+	if err := s.ensureLoaded(ctx); err != nil {
+		return err
+	}
+
+	for _, sub := range s.subscriptions {
+		if !sub.Matches(payload.University, payload.Course) {
+			continue
+		}
+
+		subPayload := payload
+		subPayload.ID = newID()
+		if sub.Group != "" || sub.Teacher != "" {
+			if payload.Diff == nil {
+				if !sub.Involves(payload.Groups, payload.Teachers) {
+					continue
+				}
+			} else if subPayload.Diff = sub.FilterDiff(payload.Diff); subPayload.Diff.Summary.Empty() {
+				continue
+			}
+		}
+
+		delivery := &models.WebhookDelivery{
+			ID:             subPayload.ID,
+			SubscriptionID: sub.ID,
+			URL:            sub.URL,
+			Event:          subPayload.Event,
+			Status:         models.DeliveryPending,
+			CreatedAt:      subPayload.Time,
+			UpdatedAt:      subPayload.Time,
+			Payload:        &subPayload,
+		}
+		s.appendLog(delivery)
+		s.start(delivery, sub.Secret)
+	}
+	return nil
+}
+
+// Deliveries возвращает журнал доставок от новых к старым.
+// Пустые subscriptionID и status не фильтруют
+func (s *WebhookService) Deliveries(subscriptionID, status string, limit int) []models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		d := s.deliveries[i]
+		if (subscriptionID == "" || d.SubscriptionID == subscriptionID) && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	return deliveries
+}
+
+// DeadLetters возвращает доставки, для которых исчерпаны попытки, от новых к старым
+func (s *WebhookService) DeadLetters() []models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dead := make([]models.WebhookDelivery, 0, len(s.deadLetters))
+	for i := len(s.deadLetters) - 1; i >= 0; i-- {
+		dead = append(dead, *s.deadLetters[i])
+	}
+	return dead
+}
+
+// Redeliver повторно отправляет доставку из dead-letter. Возвращает false, если её там нет
+// или подписка уже удалена
+func (s *WebhookService) Redeliver(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false, nil
+	}
+	if err := s.ensureLoaded(ctx); err != nil {
+		return false, err
+	}
+
+	for i, delivery := range s.deadLetters {
+		if delivery.ID != id {
+			continue
+		}
+		for _, sub := range s.subscriptions {
+			if sub.ID != delivery.SubscriptionID {
+				continue
+			}
+			s.deadLetters = append(s.deadLetters[:i], s.deadLetters[i+1:]...)
+			delivery.Status = models.DeliveryPending
+			delivery.Attempts = 0
+			delivery.Error = ""
+			delivery.UpdatedAt = time.Now().UTC()
+			s.start(delivery, sub.Secret)
+			return true, nil
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// Close прекращает приём уведомлений и ждёт текущие попытки доставки до истечения timeout.
+// Доставки, ожидающие повтора, переносятся в dead-letter
+func (s *WebhookService) Close(timeout time.Duration) bool {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// appendLog добавляет доставку в журнал, вытесняя самые старые; вызывается под s.mu
+func (s *WebhookService) appendLog(delivery *models.WebhookDelivery) {
+	s.deliveries = append(s.deliveries, delivery)
+	if len(s.deliveries) > webhookLogSize {
+		s.deliveries = s.deliveries[len(s.deliveries)-webhookLogSize:]
+	}
+}
+
+// start запускает доставку с повторами в отдельной горутине; вызывается под s.mu
+func (s *WebhookService) start(delivery *models.WebhookDelivery, secret string) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		s.finish(delivery, models.DeliveryDead, 0, fmt.Sprintf("failed to encode payload: %v", err))
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.deliver(delivery, secret, body)
+	}()
+}
+
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery, secret string, body []byte) {
+	logger := slog.With("webhook_delivery", delivery.ID, "subscription", delivery.SubscriptionID)
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case s.slots <- struct{}{}:
+		case <-s.stop:
+			s.update(delivery, models.DeliveryDead, attempt-1, 0, "service stopped before delivery", nil)
+			return
+		}
+		statusCode, err := s.send(delivery, secret, body)
+		<-s.slots
+
+		if err == nil {
+			metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+			s.update(delivery, models.DeliveryDelivered, attempt, statusCode, "", nil)
+			logger.Debug("webhook delivered", "attempt", attempt, "status", statusCode)
+			return
+		}
+
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		if attempt >= s.maxAttempts || !retryableStatus(statusCode) {
+			s.update(delivery, models.DeliveryDead, attempt, statusCode, err.Error(), nil)
+			logger.Warn("webhook delivery failed permanently", "attempts", attempt, "status", statusCode, "error", err)
+			return
+		}
+
+		backoff := s.backoff(attempt)
+		next := time.Now().Add(backoff).UTC()
+		s.update(delivery, models.DeliveryFailed, attempt, statusCode, err.Error(), &next)
+		logger.Info("webhook delivery failed, will retry", "attempt", attempt, "retry_in", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+			s.update(delivery, models.DeliveryDead, attempt, statusCode, err.Error()+" (service stopped before retry)", nil)
+			return
+		}
+	}
+}
+
+// send выполняет одну попытку доставки; успешной считается любая 2xx
+func (s *WebhookService) send(delivery *models.WebhookDelivery, secret string, body []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "schedule-api-webhooks")
+	req.Header.Set(WebhookIDHeader, delivery.ID)
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook вычисляет подпись тела запроса — подписчик проверяет её тем же способом
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff возвращает паузу перед следующей попыткой: retryBase, 2×, 4×… не больше webhookMaxBackoff
+func (s *WebhookService) backoff(attempt int) time.Duration {
+	backoff := s.retryBase
+	for i := 1; i < attempt && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// retryableStatus: сетевые ошибки, 408, 429 и 5xx повторяем, остальные 4xx — нет
+func retryableStatus(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+func (s *WebhookService) update(delivery *models.WebhookDelivery, status string, attempts, statusCode int, errMsg string, next *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery.Attempts = attempts
+	delivery.StatusCode = statusCode
+	delivery.NextAttemptAt = next
+	s.finish(delivery, status, statusCode, errMsg)
+}
+
+// finish фиксирует состояние доставки; вызывается под s.mu
+func (s *WebhookService) finish(delivery *models.WebhookDelivery, status string, statusCode int, errMsg string) {
+	delivery.Status = status
+	delivery.StatusCode = statusCode
+	delivery.Error = errMsg
+	delivery.UpdatedAt = time.Now().UTC()
+	if status == models.DeliveryDead {
+		s.deadLetters = append(s.deadLetters, delivery)
+		if len(s.deadLetters) > webhookLogSize {
+			s.deadLetters = s.deadLetters[len(s.deadLetters)-webhookLogSize:]
+		}
+	}
+}
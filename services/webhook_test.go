@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"schedule-api/config"
+	"schedule-api/models"
+)
+
+// newTestWebhookService создаёт сервис с подписками в памяти, без MinIO
+func newTestWebhookService(t *testing.T, maxAttempts int, subscriptions ...models.WebhookSubscription) *WebhookService {
+	t.Helper()
+	s := NewWebhookService(nil, &config.Config{
+		WebhookTimeout:     5 * time.Second,
+		WebhookMaxAttempts: maxAttempts,
+		WebhookRetryBase:   time.Millisecond,
+		WebhookConcurrency: 4,
+	})
+	s.loaded = true
+	s.subscriptions = subscriptions
+	t.Cleanup(func() { s.Close(5 * time.Second) })
+	return s
+}
+
+// subscriberServer отвечает статусами из statuses по очереди, затем 200, и считает запросы
+type subscriberServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	statuses []int
+}
+
+func newSubscriberServer(t *testing.T, statuses ...int) *subscriberServer {
+	s := &subscriberServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(s.requests.Add(1))
+		if n <= len(s.statuses) {
+			w.WriteHeader(s.statuses[n-1])
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// waitDelivery ждёт, пока доставка id перестанет быть в ожидании или повторе
+func waitDelivery(t *testing.T, s *WebhookService, id string) models.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, d := range s.Deliveries("", "", webhookLogSize) {
+			if d.ID == id && d.Status != models.DeliveryPending && d.Status != models.DeliveryFailed {
+				return d
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("delivery %s did not finish", id)
+	return models.WebhookDelivery{}
+}
+
+// notifyOne отправляет уведомление и возвращает единственную созданную доставку
+func notifyOne(t *testing.T, s *WebhookService) models.WebhookDelivery {
+	t.Helper()
+	if err := s.Notify(t.Context(), models.WebhookPayload{University: "kgu", Course: "1", ScheduleType: "regular", File: "a.json"}); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := s.Deliveries("", "", webhookLogSize)
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(deliveries))
+	}
+	return waitDelivery(t, s, deliveries[0].ID)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	const secret = "subscription-secret"
+
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	s := newTestWebhookService(t, 1, models.WebhookSubscription{ID: "sub-1", URL: server.URL, Secret: secret})
+	delivery := notifyOne(t, s)
+	if delivery.Status != models.DeliveryDelivered {
+		t.Fatalf("status = %s (%s)", delivery.Status, delivery.Error)
+	}
+
+	req := <-received
+	// Подписчик проверяет подпись независимо от SignWebhook
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(req.header.Get(WebhookTimestampHeader) + "."))
+	mac.Write(req.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.header.Get(WebhookSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", req.header.Get(WebhookSignatureHeader), want)
+	}
+	if req.header.Get(WebhookIDHeader) != delivery.ID || req.header.Get(WebhookEventHeader) != models.WebhookEventScheduleChanged {
+		t.Errorf("id = %q, event = %q", req.header.Get(WebhookIDHeader), req.header.Get(WebhookEventHeader))
+	}
+
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil || payload.ID != delivery.ID || payload.File != "a.json" {
+		t.Errorf("payload = %s", req.body)
+	}
+
+	if SignWebhook(secret, "1700000000", req.body) == SignWebhook("other-secret", "1700000000", req.body) ||
+		SignWebhook(secret, "1700000000", req.body) == SignWebhook(secret, "1700000001", req.body) {
+		t.Error("signature does not depend on the secret and timestamp")
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		wantStatus   string
+		wantAttempts int
+		wantCode     int
+	}{
+		{name: "delivered after retries", statuses: []int{503, 429}, maxAttempts: 5, wantStatus: models.DeliveryDelivered, wantAttempts: 3, wantCode: 200},
+		{name: "attempts exhausted", statuses: []int{500, 502, 504}, maxAttempts: 3, wantStatus: models.DeliveryDead, wantAttempts: 3, wantCode: 504},
+		{name: "client error is not retried", statuses: []int{410}, maxAttempts: 5, wantStatus: models.DeliveryDead, wantAttempts: 1, wantCode: 410},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSubscriberServer(t, tt.statuses...)
+			s := newTestWebhookService(t, tt.maxAttempts, models.WebhookSubscription{ID: "sub-1", URL: server.URL, Secret: "secret"})
+
+			delivery := notifyOne(t, s)
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts || delivery.StatusCode != tt.wantCode {
+				t.Errorf("delivery = %s after %d attempts (%d), want %s after %d (%d)",
+					delivery.Status, delivery.Attempts, delivery.StatusCode, tt.wantStatus, tt.wantAttempts, tt.wantCode)
+			}
+			if got := int(server.requests.Load()); got != tt.wantAttempts {
+				t.Errorf("requests = %d, want %d", got, tt.wantAttempts)
+			}
+
+			dead := s.DeadLetters()
+			if inDeadLetters := len(dead) == 1 && dead[0].ID == delivery.ID; inDeadLetters != (tt.wantStatus == models.DeliveryDead) {
+				t.Errorf("dead letters = %+v", dead)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &WebhookService{retryBase: time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 20: webhookMaxBackoff} {
+		if got := s.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	for status, want := range map[int]bool{0: true, 408: true, 429: true, 500: true, 503: true, 400: false, 404: false, 410: false} {
+		if got := retryableStatus(status); got != want {
+			t.Errorf("retryableStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	server := newSubscriberServer(t, http.StatusServiceUnavailable)
+	s := newTestWebhookService(t, 1, models.WebhookSubscription{ID: "sub-1", URL: server.URL, Secret: "secret"})
+
+	delivery := notifyOne(t, s)
+	if delivery.Status != models.DeliveryDead {
+		t.Fatalf("status = %s, want dead", delivery.Status)
+	}
+
+	if scheduled, err := s.Redeliver(t.Context(), "unknown"); scheduled || err != nil {
+		t.Errorf("unknown delivery: scheduled = %v, err = %v", scheduled, err)
+	}
+	if scheduled, err := s.Redeliver(t.Context(), delivery.ID); !scheduled || err != nil {
+		t.Fatalf("redeliver: scheduled = %v, err = %v", scheduled, err)
+	}
+	if dead := s.DeadLetters(); len(dead) != 0 {
+		t.Errorf("delivery stays in dead letters: %+v", dead)
+	}
+
+	redelivered := waitDelivery(t, s, delivery.ID)
+	if redelivered.Status != models.DeliveryDelivered || redelivered.Attempts != 1 || redelivered.Error != "" {
+		t.Errorf("redelivered = %+v", redelivered)
+	}
+
+	// Подписка удалена: повторять некуда
+	s.subscriptions = nil
+	s.deadLetters = append(s.deadLetters, &models.WebhookDelivery{ID: "orphan", SubscriptionID: "sub-1"})
+	if scheduled, err := s.Redeliver(t.Context(), "orphan"); scheduled || err != nil {
+		t.Errorf("orphan delivery: scheduled = %v, err = %v", scheduled, err)
+	}
+}
+
+func TestWebhookRedeliverLoadsSubscriptions(t *testing.T) {
+	minio, err := NewMinIOService(&config.Config{MinIOEndpoint: "127.0.0.1:1", TargetBucket: "schedules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewWebhookService(minio, &config.Config{WebhooksObject: "webhooks/subscriptions.json", WebhookConcurrency: 1})
+
+	// Подписки не загружены при запуске: повтор пытается их догрузить и возвращает ошибку хранилища
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if scheduled, err := s.Redeliver(ctx, "any"); scheduled || err == nil {
+		t.Errorf("scheduled = %v, err = %v, want a storage error", scheduled, err)
+	}
+}
+
+func TestWebhookNotifyScope(t *testing.T) {
+	server := newSubscriberServer(t)
+	subscriptions := []models.WebhookSubscription{
+		{ID: "all", URL: server.URL},
+		{ID: "kgu", URL: server.URL, University: "kgu"},
+		{ID: "agtu", URL: server.URL, University: "agtu"},
+		{ID: "course-2", URL: server.URL, University: "kgu", Course: "2"},
+		{ID: "group", URL: server.URL, Group: "23101"},
+		{ID: "other-group", URL: server.URL, Group: "23199"},
+		{ID: "teacher", URL: server.URL, Teacher: "Гареева Г. А."},
+		{ID: "teacher-id", URL: server.URL, Teacher: models.TeacherID("Петров П.П.")},
+		{ID: "group-and-teacher", URL: server.URL, Group: "23102", Teacher: "Гареева Г.А."},
+	}
+
+	diff := &models.ScheduleDiff{
+		Changes: []models.LessonChange{
+			{Kind: models.LessonAdded, Group: "23101", After: &models.Lesson{Subject: "Физика", Teacher: "Гареева Г.А."}},
+			{Kind: models.LessonRemoved, Group: "23102", Before: &models.Lesson{Subject: "Химия", Teacher: "Иванов И.И."}},
+		},
+		Summary: models.DiffSummary{Added: 1, Removed: 1, Groups: []string{"23101", "23102"}},
+	}
+
+	tests := []struct {
+		name    string
+		payload models.WebhookPayload
+		want    []string
+	}{
+		{
+			name:    "regular schedule diff",
+			payload: models.WebhookPayload{University: "kgu", Course: "1", Diff: diff},
+			want:    []string{"all", "group", "kgu", "teacher"},
+		},
+		{
+			name:    "diff without changes",
+			payload: models.WebhookPayload{University: "kgu", Course: "1", Diff: &models.ScheduleDiff{}},
+			want:    []string{},
+		},
+		{
+			name: "replacements",
+			payload: models.WebhookPayload{University: "kgu", Course: "1", ScheduleType: "replacements",
+				Groups: []string{"23101", "23102"}, Teachers: []string{"Петров П.П."}},
+			want: []string{"all", "group", "kgu", "teacher-id"},
+		},
+		{
+			name: "exams",
+			payload: models.WebhookPayload{University: "kgu", Course: "2", ScheduleType: "exams",
+				Groups: []string{"23102"}, Teachers: []string{"Гареева Г.А."}},
+			want: []string{"all", "course-2", "group-and-teacher", "kgu", "teacher"},
+		},
+		{
+			name:    "file without groups",
+			payload: models.WebhookPayload{University: "agtu", Course: "1", ScheduleType: "replacements"},
+			want:    []string{"agtu", "all"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestWebhookService(t, 1, subscriptions...)
+			if err := s.Notify(t.Context(), tt.payload); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0)
+			for _, d := range s.Deliveries("", "", webhookLogSize) {
+				got = append(got, d.SubscriptionID)
+				// Подписке на группу diff сужается до её занятий
+				if d.SubscriptionID == "group" && d.Payload.Diff != nil && d.Payload.Diff.Summary.Added+d.Payload.Diff.Summary.Removed != 1 {
+					t.Errorf("group subscription diff = %+v", d.Payload.Diff.Summary)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("subscriptions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}